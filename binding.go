@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/data/binding"
+	"jubilant-spork/iul"
+)
+
+// ProjectBinding exposes the project collections as fyne data bindings. The
+// Project stays the source of truth: mutate it, then call Sync.
+type ProjectBinding struct {
+	Project *iul.Project
+	Files   binding.UntypedList
+	Authors binding.UntypedList
+	Control binding.UntypedList
+	Changes binding.UntypedList
+	// Problems are the problems found by the last validation.
+	Problems binding.UntypedList
+
+	fileProblems map[string][]iul.Problem
+}
+
+func NewProjectBinding(project *iul.Project) *ProjectBinding {
+	projectBinding := &ProjectBinding{
+		Project:  project,
+		Files:    binding.NewUntypedList(),
+		Authors:  binding.NewUntypedList(),
+		Control:  binding.NewUntypedList(),
+		Changes:  binding.NewUntypedList(),
+		Problems: binding.NewUntypedList(),
+	}
+	projectBinding.Sync()
+	return projectBinding
+}
+
+func (b *ProjectBinding) Sync() {
+	_ = b.Files.Set(toUntyped(b.Project.Files))
+	_ = b.Authors.Set(toUntyped(b.Project.Authors))
+	_ = b.Control.Set(toUntyped(b.Project.Control.Rows))
+	_ = b.Changes.Set(toUntyped(b.Project.Changes()))
+}
+
+func (b *ProjectBinding) SetProblems(problems []iul.Problem) {
+	b.fileProblems = iul.FileProblems(problems)
+	_ = b.Problems.Set(toUntyped(problems))
+}
+
+// FileProblems returns the problems of a single file.
+func (b *ProjectBinding) FileProblems(file iul.CheckedFile) []iul.Problem {
+	return b.fileProblems[file.FileName]
+}
+
+func (b *ProjectBinding) SetAuthor(index int, author iul.Author) {
+	if index < 0 || index >= len(b.Project.Authors) {
+		return
+	}
+	b.Project.Authors[index] = author
+	_ = b.Authors.SetValue(index, author)
+}
+
+func toUntyped[T any](values []T) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+func bindingValue[T any](list binding.UntypedList, index int) T {
+	var zero T
+	value, err := list.GetValue(index)
+	if err != nil {
+		return zero
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero
+	}
+	return typed
+}
+
+// refreshOnChange refreshes the object whenever the list length or any of its
+// items changes.
+func refreshOnChange(list binding.DataList, object fyne.CanvasObject) {
+	onListChange(list, object.Refresh)
+}
+
+// onListChange calls callback whenever the list length or any of its items
+// changes.
+func onListChange(list binding.DataList, callback func()) {
+	listened := make(map[binding.DataItem]bool)
+	list.AddListener(binding.NewDataListener(func() {
+		for i := 0; i < list.Length(); i++ {
+			item, err := list.GetItem(i)
+			if err != nil || listened[item] {
+				continue
+			}
+			listened[item] = true
+			item.AddListener(binding.NewDataListener(callback))
+		}
+		callback()
+	}))
+}
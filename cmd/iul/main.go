@@ -0,0 +1,552 @@
+// Command iul is the command line frontend of the ИУЛ generator. It scans
+// folders, renders the information and certification sheet and verifies
+// rendered manifests using the same library as the GUI.
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"jubilant-spork/iul"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Usage:
+  iul scan [flags] DIR
+  iul render [flags] DIR
+  iul check [flags] DIR
+  iul verify MANIFEST
+  iul compare PREVIOUS CURRENT
+  iul package [flags] MANIFEST
+  iul sign -key KEY.p12 [flags] MANIFEST
+  iul verify-signatures [flags] MANIFEST
+  iul templates [flags]
+  iul export-template FILE
+
+Run "iul <command> -h" for the flags of a command.
+`
+
+const passwordEnv = "IUL_KEY_PASSWORD"
+
+var (
+	errMismatch   = errors.New("verification failed")
+	errValidation = errors.New("validation failed")
+)
+
+type timestampFlags struct {
+	source    string
+	issueDate string
+}
+
+func (f *timestampFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.source, "timestamp", string(iul.TimestampModified), "timestamp source: modified, birth, issue_date or metadata")
+	flags.StringVar(&f.issueDate, "issue-date", "", "issue date for -timestamp issue_date, "+iul.IssueDateLayout)
+}
+
+func (f *timestampFlags) options() (iul.TimestampOptions, error) {
+	options := iul.TimestampOptions{Source: iul.TimestampSource(f.source)}
+	valid := false
+	for _, source := range iul.TimestampSources {
+		valid = valid || source == options.Source
+	}
+	if !valid {
+		return options, fmt.Errorf("unknown timestamp source %q", f.source)
+	}
+	if f.issueDate != "" {
+		issueDate, err := time.ParseInLocation(iul.IssueDateLayout, f.issueDate, time.Local)
+		if err != nil {
+			return options, err
+		}
+		options.IssueDate = issueDate
+	}
+	if options.Source == iul.TimestampIssueDate && options.IssueDate.IsZero() {
+		return options, fmt.Errorf("-timestamp %s requires -issue-date", iul.TimestampIssueDate)
+	}
+	return options, nil
+}
+
+type workbookFlags struct {
+	path    string
+	options iul.WorkbookOptions
+}
+
+func (f *workbookFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.path, "workbook", "", "control workbook (.xlsx, .xls or .ods); searched two levels up from DIR when empty")
+	flags.BoolVar(&f.options.RawValues, "raw-values", false, "read the stored cell values instead of the formatted ones")
+	flags.BoolVar(&f.options.Recalculate, "recalculate", false, "evaluate every formula instead of using the cached values")
+}
+
+type cacheFlags struct {
+	disabled bool
+	rehash   bool
+}
+
+func (f *cacheFlags) register(flags *flag.FlagSet) {
+	flags.BoolVar(&f.disabled, "no-cache", false, "do not read or update the checksum cache")
+	flags.BoolVar(&f.rehash, "rehash", false, "read every file again and refresh the checksum cache")
+}
+
+// apply opens the checksum cache into options unless it is disabled.
+func (f *cacheFlags) apply(options *iul.ScanOptions) error {
+	options.ForceRehash = f.rehash
+	if f.disabled {
+		return nil
+	}
+	cachePath, err := iul.DefaultChecksumCachePath()
+	if err != nil {
+		return err
+	}
+	options.Cache, err = iul.OpenChecksumCache(cachePath)
+	return err
+}
+
+func runScan(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("scan", flag.ExitOnError)
+	var timestamps timestampFlags
+	timestamps.register(flags)
+	var cache cacheFlags
+	cache.register(flags)
+	expandArchives := flags.Bool("expand-archives", false, "list the entries of ZIP and TAR archives")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	profileName := flags.String("format", iul.DefaultFormatProfile, "format profile for sizes and dates")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("scan expects exactly one directory")
+	}
+	timestampOptions, err := timestamps.options()
+	if err != nil {
+		return err
+	}
+	scanOptions := iul.ScanOptions{Timestamps: timestampOptions, ExpandArchives: *expandArchives}
+	if err := cache.apply(&scanOptions); err != nil {
+		return err
+	}
+	files, err := iul.Scan(ctx, flags.Arg(0), scanOptions)
+	if err != nil {
+		return err
+	}
+	if err := scanOptions.Cache.Save(); err != nil {
+		return err
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(files)
+	}
+	profile := iul.FindFormatProfile(*profileName)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, file := range files {
+		row := profile.FormatFile(file)
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", row.FileName, row.Checksum, row.FileSize, row.CreatedAt)
+	}
+	return writer.Flush()
+}
+
+func runRender(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	var timestamps timestampFlags
+	timestamps.register(flags)
+	var cache cacheFlags
+	cache.register(flags)
+	var workbook workbookFlags
+	workbook.register(flags)
+	expandArchives := flags.Bool("expand-archives", false, "list the entries of ZIP and TAR archives")
+	templatePath := flags.String("template", "", "template document; the built-in template when empty")
+	outputPattern := flags.String("output", "result.docx", "output document; {Control.F7} takes a control sheet cell, {n} the next free version")
+	autoVersion := flags.Bool("auto-version", false, "add a _v2, _v3, ... suffix instead of overwriting an existing output")
+	rulesPath := flags.String("rules", "", "validation rules (.json); the rules of the user config dir when empty")
+	force := flags.Bool("force", false, "render despite validation errors")
+	comparePath := flags.String("compare", "", "manifest of the previous issue that fills {{Items_Status}} and {{Removed_...}}")
+	keepBackup := flags.Bool("backup", false, "move a previous output and its manifest aside instead of overwriting them")
+	profileName := flags.String("format", iul.DefaultFormatProfile, "format profile for sizes and dates")
+	firstPageRows := flags.Int("first-page-rows", iul.DefaultFirstPageRows, "item rows on the first sheet, 0 disables pagination")
+	continuationRows := flags.Int("continuation-rows", iul.DefaultContinuationPageRows, "item rows on each continuation sheet")
+	includeWorkbook := flags.Bool("include-workbook", false, "list the workbook as a regular item")
+	includeTemplate := flags.Bool("include-template", false, "list the template as a regular item")
+	excludeDuplicates := flags.Bool("exclude-duplicates", false, "leave out files with the same checksum and size as an earlier file")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("render expects exactly one directory")
+	}
+	timestampOptions, err := timestamps.options()
+	if err != nil {
+		return err
+	}
+
+	project, err := openProject(ctx, flags.Arg(0), workbook, timestampOptions)
+	if err != nil {
+		return err
+	}
+	profile := iul.FindFormatProfile(*profileName)
+	control := profile.FormatControl(project.Control)
+	outputPath := iul.ResolveOutputPath(*outputPattern, control, *autoVersion)
+	scanOptions := iul.ScanOptions{
+		Timestamps:     timestampOptions,
+		Exclude:        []string{outputPath, iul.ManifestPath(outputPath)},
+		Output:         iul.NewOutputMatcher(*outputPattern, control),
+		ExpandArchives: *expandArchives,
+	}
+	if err := cache.apply(&scanOptions); err != nil {
+		return err
+	}
+	project.Files, err = iul.Scan(ctx, project.Folder, scanOptions)
+	if err != nil {
+		return err
+	}
+	if err := scanOptions.Cache.Save(); err != nil {
+		return err
+	}
+	if *excludeDuplicates {
+		project.Files = iul.RemoveDuplicates(project.Files)
+	}
+	if err := validate(project, *templatePath, *rulesPath, *force); err != nil {
+		return err
+	}
+	if _, err := os.Stat(outputPath); err == nil && !*keepBackup {
+		return fmt.Errorf("%s: %w; use -backup or -auto-version", outputPath, fs.ErrExist)
+	}
+	var previous *iul.Manifest
+	if *comparePath != "" {
+		previous, err = iul.LoadManifest(*comparePath)
+		if err != nil {
+			return err
+		}
+	}
+	err = iul.Render(ctx, project, iul.RenderOptions{
+		TemplatePath: *templatePath,
+		OutputPath:   outputPath,
+		KeepBackup:   *keepBackup,
+		Items:        iul.ItemOptions{IncludeWorkbook: *includeWorkbook, IncludeTemplate: *includeTemplate},
+		Pagination:   iul.Pagination{FirstPageRows: *firstPageRows, ContinuationPageRows: *continuationRows},
+		Profile:      profile,
+		Timestamps:   timestampOptions,
+		Previous:     previous,
+	})
+	if err != nil {
+		return err
+	}
+	if err := saveRevision(project.Folder, outputPath); err != nil {
+		return err
+	}
+	fmt.Println(outputPath)
+	return nil
+}
+
+// saveRevision records the render in the project history, as the GUI does.
+func saveRevision(folder string, outputPath string) error {
+	storeDir, err := iul.DefaultProjectStoreDir()
+	if err != nil {
+		return err
+	}
+	_, err = iul.NewProjectStore(storeDir).SaveRevision(folder, iul.ManifestPath(outputPath))
+	return err
+}
+
+// openProject reads the control workbook of dir, searched two levels up when
+// no workbook is given. The files are left for the caller to scan.
+func openProject(ctx context.Context, dir string, workbook workbookFlags, timestamps iul.TimestampOptions) (*iul.Project, error) {
+	project := &iul.Project{Folder: dir, WorkbookPath: workbook.path}
+	if project.WorkbookPath == "" {
+		project.WorkbookPath = iul.SearchWorkbook(filepath.Join(project.Folder, "../.."), filepath.Base(project.Folder))
+	}
+	if project.WorkbookPath == "" {
+		return project, nil
+	}
+	var err error
+	project.Workbook, err = iul.ChecksumPath(ctx, project.WorkbookPath, timestamps)
+	if err != nil {
+		return nil, err
+	}
+	control, authors, err := iul.ReadWorkbook(ctx, project.WorkbookPath, workbook.options)
+	if err != nil {
+		return nil, err
+	}
+	project.SetWorkbookData(control, authors, iul.DefaultAuthorTitles)
+	return project, nil
+}
+
+func runCheck(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	var cache cacheFlags
+	cache.register(flags)
+	var workbook workbookFlags
+	workbook.register(flags)
+	expandArchives := flags.Bool("expand-archives", false, "list the entries of ZIP and TAR archives")
+	templatePath := flags.String("template", "", "template document; the built-in template when empty")
+	rulesPath := flags.String("rules", "", "validation rules (.json); the rules of the user config dir when empty")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("check expects exactly one directory")
+	}
+	timestamps := iul.TimestampOptions{Source: iul.TimestampModified}
+	project, err := openProject(ctx, flags.Arg(0), workbook, timestamps)
+	if err != nil {
+		return err
+	}
+	scanOptions := iul.ScanOptions{Timestamps: timestamps, ExpandArchives: *expandArchives}
+	if err := cache.apply(&scanOptions); err != nil {
+		return err
+	}
+	project.Files, err = iul.Scan(ctx, project.Folder, scanOptions)
+	if err != nil {
+		return err
+	}
+	if err := scanOptions.Cache.Save(); err != nil {
+		return err
+	}
+	if err := validate(project, *templatePath, *rulesPath, false); err != nil {
+		return err
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+// validate prints the problems of the project and fails on errors unless
+// forced.
+func validate(project *iul.Project, templatePath string, rulesPath string, force bool) error {
+	if rulesPath == "" {
+		var err error
+		rulesPath, err = iul.DefaultValidationRulesPath()
+		if err != nil {
+			return err
+		}
+	}
+	rules, err := iul.LoadValidationRules(rulesPath)
+	if err != nil {
+		return err
+	}
+	templateFields, err := iul.TemplateFields(templatePath)
+	if err != nil {
+		return err
+	}
+	problems := iul.Validate(project, templateFields, rules)
+	for _, problem := range problems {
+		line := fmt.Sprintf("%-7s  %s  %s %s %s", strings.ToUpper(string(problem.Severity)), problem.Rule, problem.Subject, problem.Value, problem.Cell)
+		fmt.Fprintln(os.Stderr, strings.TrimSpace(line))
+	}
+	if iul.HasErrors(problems) && !force {
+		return errValidation
+	}
+	return nil
+}
+
+func printMismatches(mismatches []iul.Mismatch) {
+	for _, mismatch := range mismatches {
+		if mismatch.Missing {
+			fmt.Printf("MISSING  %s\n", mismatch.FileName)
+			continue
+		}
+		fmt.Printf("CHANGED  %s  %s/%d -> %s/%d\n", mismatch.FileName,
+			mismatch.Expected.Checksum, mismatch.Expected.FileSize,
+			mismatch.Actual.Checksum, mismatch.Actual.FileSize)
+	}
+}
+
+func runVerify(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("verify expects exactly one manifest")
+	}
+	manifest, err := iul.LoadManifest(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	mismatches, err := iul.VerifyManifest(ctx, manifest)
+	if err != nil {
+		return err
+	}
+	printMismatches(mismatches)
+	if len(mismatches) > 0 {
+		return errMismatch
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+func runCompare(args []string) error {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	all := flags.Bool("all", false, "list unchanged files as well")
+	_ = flags.Parse(args)
+	if flags.NArg() != 2 {
+		return errors.New("compare expects the previous and the current manifest")
+	}
+	previous, err := iul.LoadManifest(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	current, err := iul.LoadManifest(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	for _, change := range iul.CompareFiles(previous.Files, current.Files) {
+		if change.Status != iul.StatusUnchanged || *all {
+			fmt.Printf("%-9s  %s\n", strings.ToUpper(string(change.Status)), change.File.FileName)
+		}
+	}
+	return nil
+}
+
+func runPackage(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("package", flag.ExitOnError)
+	outputPath := flags.String("output", "", "package path; the manifest name with "+iul.PackageExtension+" when empty")
+	includePDF := flags.Bool("pdf", false, "add a PDF copy of the document made with LibreOffice")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("package expects exactly one manifest")
+	}
+	manifestPath := flags.Arg(0)
+	packagePath := *outputPath
+	if packagePath == "" {
+		packagePath = strings.TrimSuffix(manifestPath, iul.ManifestSuffix) + iul.PackageExtension
+	}
+	err := iul.ExportPackage(ctx, manifestPath, packagePath, iul.PackageOptions{PDF: *includePDF})
+	var mismatchErr *iul.PackageMismatchError
+	if errors.As(err, &mismatchErr) {
+		printMismatches(mismatchErr.Mismatches)
+		return errMismatch
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(packagePath)
+	return nil
+}
+
+func runSign(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("sign", flag.ExitOnError)
+	keyPath := flags.String("key", "", "PKCS#12 (.p12, .pfx) file with the signing key and certificate")
+	password := flags.String("password", "", "PKCS#12 password; "+passwordEnv+" is used when empty")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 || *keyPath == "" {
+		return errors.New("sign expects -key and exactly one manifest")
+	}
+	if *password == "" {
+		*password = os.Getenv(passwordEnv)
+	}
+	signer, err := iul.LoadPKCS12Signer(*keyPath, *password)
+	if err != nil {
+		return err
+	}
+	signed, err := iul.SignManifest(ctx, signer, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, signedPath := range signed {
+		fmt.Println(iul.SignaturePath(signedPath))
+	}
+	return nil
+}
+
+func runVerifySignatures(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("verify-signatures", flag.ExitOnError)
+	rootsPath := flags.String("roots", "", "PEM file with trusted root certificates; only signatures are checked when empty")
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("verify-signatures expects exactly one manifest")
+	}
+	var verifier iul.PKCS7Verifier
+	if *rootsPath != "" {
+		data, err := os.ReadFile(*rootsPath)
+		if err != nil {
+			return err
+		}
+		verifier.Roots = x509.NewCertPool()
+		if !verifier.Roots.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates in %s", *rootsPath)
+		}
+	}
+	checks, err := iul.VerifySignatures(ctx, verifier, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	failed := false
+	for _, check := range checks {
+		if check.Err != nil {
+			failed = true
+			fmt.Printf("INVALID  %s  %s\n", check.Path, check.Err)
+			continue
+		}
+		fmt.Printf("OK       %s  %s\n", check.Path, check.Signer.Subject)
+	}
+	if failed {
+		return errMismatch
+	}
+	return nil
+}
+
+func runTemplates(args []string) error {
+	flags := flag.NewFlagSet("templates", flag.ExitOnError)
+	defaultDir, err := iul.DefaultTemplateLibraryDir()
+	if err != nil {
+		return err
+	}
+	dir := flags.String("dir", defaultDir, "template library directory")
+	_ = flags.Parse(args)
+	templates, err := iul.LoadTemplateLibrary(*dir)
+	if err != nil {
+		return err
+	}
+	for _, template := range templates {
+		fmt.Printf("%s\n  %s\n  %s\n", template.Name, template.Path, strings.Join(template.Fields, ", "))
+	}
+	return nil
+}
+
+func runExportTemplate(args []string) error {
+	flags := flag.NewFlagSet("export-template", flag.ExitOnError)
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("export-template expects exactly one output file")
+	}
+	return iul.ExportBuiltinTemplate(flags.Arg(0))
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch os.Args[1] {
+	case "scan":
+		err = runScan(ctx, os.Args[2:])
+	case "render":
+		err = runRender(ctx, os.Args[2:])
+	case "check":
+		err = runCheck(ctx, os.Args[2:])
+	case "verify":
+		err = runVerify(ctx, os.Args[2:])
+	case "compare":
+		err = runCompare(os.Args[2:])
+	case "package":
+		err = runPackage(ctx, os.Args[2:])
+	case "sign":
+		err = runSign(ctx, os.Args[2:])
+	case "verify-signatures":
+		err = runVerifySignatures(ctx, os.Args[2:])
+	case "templates":
+		err = runTemplates(os.Args[2:])
+	case "export-template":
+		err = runExportTemplate(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "iul:", err)
+		os.Exit(1)
+	}
+}
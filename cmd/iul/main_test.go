@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"jubilant-spork/iul"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testProject creates a project folder without a control workbook, nested so
+// that the workbook search two levels up stays inside the temporary dir.
+func testProject(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	dir := filepath.Join(t.TempDir(), "a", "b", "project")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.pdf"), []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestRenderKeepsExistingOutput(t *testing.T) {
+	dir := testProject(t)
+	output := filepath.Join(t.TempDir(), "result.docx")
+	args := []string{"-no-cache", "-force", "-output", output, dir}
+	if err := runRender(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runRender(context.Background(), args); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("second runRender() error = %v, want %v", err, fs.ErrExist)
+	}
+	if got, err := os.ReadFile(output); err != nil || string(got) != string(rendered) {
+		t.Errorf("second runRender() changed the existing output: %v", err)
+	}
+
+	if err := runRender(context.Background(), append([]string{"-backup"}, args...)); err != nil {
+		t.Errorf("runRender(-backup) error = %v", err)
+	}
+	if err := runRender(context.Background(), append([]string{"-auto-version"}, args...)); err != nil {
+		t.Errorf("runRender(-auto-version) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(output), "result_v2.docx")); err != nil {
+		t.Errorf("runRender(-auto-version) wrote no new version: %v", err)
+	}
+}
+
+func TestRenderSavesRevision(t *testing.T) {
+	dir := testProject(t)
+	output := filepath.Join(t.TempDir(), "result_v{n}.docx")
+	for range 2 {
+		if err := runRender(context.Background(), []string{"-no-cache", "-force", "-output", output, dir}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	storeDir, err := iul.DefaultProjectStoreDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	revisions, err := iul.NewProjectStore(storeDir).Revisions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[1].Output != filepath.Join(filepath.Dir(output), "result_v2.docx") {
+		t.Errorf("Revisions() = %+v, want the two renders", revisions)
+	}
+}
+
+func TestTimestampFlagsRequireIssueDate(t *testing.T) {
+	flags := timestampFlags{source: string(iul.TimestampIssueDate)}
+	if _, err := flags.options(); err == nil {
+		t.Error("options() without -issue-date returned no error")
+	}
+	flags.issueDate = "01.03.2024"
+	options, err := flags.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.IssueDate.IsZero() {
+		t.Error("options() dropped the issue date")
+	}
+}
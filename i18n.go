@@ -0,0 +1,104 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"jubilant-spork/iul"
+	"log"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultLanguage     = "ru"
+	LanguageNameKey     = "language.name"
+	GroupSeparatorKey   = "number.group_separator"
+	DecimalSeparatorKey = "number.decimal_separator"
+)
+
+var sizeUnitKeys = []string{"size.unit.b", "size.unit.kb", "size.unit.mb", "size.unit.gb", "size.unit.tb"}
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var catalogues = loadCatalogues()
+var currentLanguage = DefaultLanguage
+
+func loadCatalogues() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		log.Fatal(err)
+	}
+	result := make(map[string]map[string]string)
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			log.Fatal(err)
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			log.Fatalf("Failed to parse locale %s due to %s", entry.Name(), err)
+		}
+		result[strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))] = messages
+	}
+	return result
+}
+
+// Languages returns the codes of all bundled locales, the default one first.
+func Languages() []string {
+	languages := make([]string, 0, len(catalogues))
+	for language := range catalogues {
+		if language != DefaultLanguage {
+			languages = append(languages, language)
+		}
+	}
+	sort.Strings(languages)
+	return append([]string{DefaultLanguage}, languages...)
+}
+
+func SetLanguage(language string) {
+	if _, ok := catalogues[language]; ok {
+		currentLanguage = language
+	}
+}
+
+func CurrentLanguage() string {
+	return currentLanguage
+}
+
+// T looks the key up in the current locale, falling back to the default
+// locale and finally to the key itself. Arguments are applied with fmt.Sprintf.
+func T(key string, args ...any) string {
+	message, ok := catalogues[currentLanguage][key]
+	if !ok {
+		message, ok = catalogues[DefaultLanguage][key]
+	}
+	if !ok {
+		message = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+func languageName(language string) string {
+	if name, ok := catalogues[language][LanguageNameKey]; ok {
+		return name
+	}
+	return language
+}
+
+func numberLocale() iul.NumberLocale {
+	units := make([]string, len(sizeUnitKeys))
+	for i, key := range sizeUnitKeys {
+		units[i] = T(key)
+	}
+	return iul.NumberLocale{
+		GroupSeparator:   T(GroupSeparatorKey),
+		DecimalSeparator: T(DecimalSeparatorKey),
+		SizeUnits:        units,
+	}
+}
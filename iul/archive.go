@@ -0,0 +1,139 @@
+package iul
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveSeparator joins the archive name and the entry path in the file name
+// of an archive entry, e.g. "archive.zip/inner/file.pdf".
+const ArchiveSeparator = "/"
+
+var archiveExtensions = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// IsArchive tells whether the file name has an extension ReadArchive supports.
+func IsArchive(fileName string) bool {
+	lowerName := strings.ToLower(fileName)
+	for _, extension := range archiveExtensions {
+		if strings.HasSuffix(lowerName, extension) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadArchive lists the regular files of the archive dir/archiveName with
+// their checksums and sizes. Entries are named archiveName/entry/path and carry
+// the archive name in CheckedFile.Archive.
+func ReadArchive(ctx context.Context, dir string, archiveName string, timestamps TimestampOptions) ([]CheckedFile, error) {
+	archivePath := filepath.Join(dir, archiveName)
+	if strings.HasSuffix(strings.ToLower(archiveName), ".zip") {
+		return readZipArchive(ctx, archivePath, archiveName, timestamps)
+	}
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	if !strings.HasSuffix(strings.ToLower(archiveName), ".tar") {
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+	return readTarArchive(ctx, reader, archiveName, timestamps)
+}
+
+func archiveEntry(archiveName string, entryName string, checksum uint32, size int64, modTime time.Time, timestamps TimestampOptions) CheckedFile {
+	entry := CheckedFile{
+		FileName:        archiveName + ArchiveSeparator + path.Clean(strings.TrimPrefix(entryName, "/")),
+		Archive:         archiveName,
+		Checksum:        formatChecksum(checksum),
+		FileSize:        size,
+		CreatedAt:       modTime,
+		TimestampSource: TimestampModified,
+		ModTime:         modTime,
+	}
+	if timestamps.Source == TimestampIssueDate && !timestamps.IssueDate.IsZero() {
+		entry.CreatedAt = timestamps.IssueDate
+		entry.TimestampSource = TimestampIssueDate
+	}
+	return entry
+}
+
+func readZipArchive(ctx context.Context, archivePath string, archiveName string, timestamps TimestampOptions) ([]CheckedFile, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var entries []CheckedFile
+	for _, zipFile := range reader.File {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if zipFile.FileInfo().IsDir() {
+			continue
+		}
+		checksum := zipFile.CRC32
+		// The central directory CRC is used as is unless a writer left it
+		// empty for a non-empty entry.
+		if checksum == 0 && zipFile.UncompressedSize64 > 0 {
+			checksum, err = zipEntryChecksum(ctx, zipFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, archiveEntry(archiveName, zipFile.Name, checksum, int64(zipFile.UncompressedSize64), zipFile.Modified, timestamps))
+	}
+	return entries, nil
+}
+
+func zipEntryChecksum(ctx context.Context, zipFile *zip.File) (uint32, error) {
+	entry, err := zipFile.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer entry.Close()
+	hasher := crc32.NewIEEE()
+	if _, err := io.Copy(hasher, contextReader{ctx: ctx, reader: entry}); err != nil {
+		return 0, err
+	}
+	return hasher.Sum32(), nil
+}
+
+func readTarArchive(ctx context.Context, reader io.Reader, archiveName string, timestamps TimestampOptions) ([]CheckedFile, error) {
+	tarReader := tar.NewReader(contextReader{ctx: ctx, reader: reader})
+	var entries []CheckedFile
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		hasher := crc32.NewIEEE()
+		size, err := io.Copy(hasher, tarReader)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, archiveEntry(archiveName, header.Name, hasher.Sum32(), size, header.ModTime, timestamps))
+	}
+}
@@ -0,0 +1,183 @@
+package iul
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeZip(t *testing.T, dir string, name string, entries map[string]string) {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	if _, err := writer.Create("inner/"); err != nil {
+		t.Fatal(err)
+	}
+	for entryName, content := range entries {
+		entry, err := writer.Create(entryName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := entry.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A stored entry whose writer left the CRC empty.
+	raw, err := writer.CreateRaw(&zip.FileHeader{Name: "raw.txt", Method: zip.Store, CompressedSize64: 5, UncompressedSize64: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, name, buffer.String())
+}
+
+func writeTarGz(t *testing.T, dir string, name string, entries map[string]string) {
+	t.Helper()
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	writer := tar.NewWriter(gzipWriter)
+	if err := writer.WriteHeader(&tar.Header{Name: "docs/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	for entryName, content := range entries {
+		header := &tar.Header{Name: entryName, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(content)), ModTime: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, name, buffer.String())
+}
+
+func fileNames(files []CheckedFile) []string {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.FileName
+	}
+	return names
+}
+
+func TestScanExpandArchives(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, dir, "a.zip", map[string]string{"inner/file.pdf": "hello"})
+	writeTarGz(t, dir, "b.tar.gz", map[string]string{"docs/plan.dwg": "hello"})
+	writeFile(t, dir, "c.pdf", "c")
+
+	files, err := Scan(context.Background(), dir, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.zip", "b.tar.gz", "c.pdf"}; !reflect.DeepEqual(fileNames(files), want) {
+		t.Errorf("Scan() = %v, want %v without ExpandArchives", fileNames(files), want)
+	}
+
+	files, err = Scan(context.Background(), dir, ScanOptions{ExpandArchives: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a.zip", "a.zip/inner/file.pdf", "a.zip/raw.txt", "b.tar.gz", "b.tar.gz/docs/plan.dwg", "c.pdf"}
+	if !reflect.DeepEqual(fileNames(files), want) {
+		t.Fatalf("Scan(ExpandArchives) = %v, want %v", fileNames(files), want)
+	}
+	for index, archive := range map[int]string{1: "a.zip", 2: "a.zip", 4: "b.tar.gz"} {
+		entry := files[index]
+		if entry.Checksum != "3610A686" || entry.FileSize != 5 {
+			t.Errorf("%s = %s/%d, want 3610A686/5", entry.FileName, entry.Checksum, entry.FileSize)
+		}
+		if entry.Archive != archive {
+			t.Errorf("%s.Archive = %q, want %q", entry.FileName, entry.Archive, archive)
+		}
+	}
+	if !files[4].CreatedAt.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("tar entry CreatedAt = %v, want the entry modification time", files[4].CreatedAt)
+	}
+
+	if stale, err := StaleFiles(dir, files, ScanOptions{}); err != nil || len(stale) != 0 {
+		t.Errorf("StaleFiles() = %v, %v, archive entries must not be reported", stale, err)
+	}
+}
+
+func TestVerifyArchiveEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, dir, "a.zip", map[string]string{"inner/file.pdf": "hello", "inner/other.pdf": "other"})
+	files, err := Scan(context.Background(), dir, ScanOptions{ExpandArchives: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mismatches, err := Verify(context.Background(), dir, files); err != nil || len(mismatches) != 0 {
+		t.Fatalf("Verify() = %v, %v on an unchanged archive", mismatches, err)
+	}
+
+	writeZip(t, dir, "a.zip", map[string]string{"inner/file.pdf": "changed"})
+	mismatches, err := Verify(context.Background(), dir, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := make(map[string]bool)
+	for _, mismatch := range mismatches {
+		status[mismatch.FileName] = mismatch.Missing
+	}
+	want := map[string]bool{"a.zip": false, "a.zip/inner/file.pdf": false, "a.zip/inner/other.pdf": true}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("Verify() mismatches = %v, want %v", status, want)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "a.zip")); err != nil {
+		t.Fatal(err)
+	}
+	mismatches, err = Verify(context.Background(), dir, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != len(files) {
+		t.Errorf("Verify() reported %d of %d files after the archive was removed", len(mismatches), len(files))
+	}
+}
+
+func TestUpdateFilesReplacesArchiveEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "b.pdf", "b")
+	writeZip(t, dir, "a.zip", map[string]string{"one.pdf": "1", "two.pdf": "2"})
+	options := ScanOptions{ExpandArchives: true}
+	files, err := Scan(context.Background(), dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := &Project{Files: files}
+
+	writeZip(t, dir, "a.zip", map[string]string{"three.pdf": "3"})
+	changed, removed, err := Rescan(context.Background(), dir, []string{"a.zip"}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	project.UpdateFiles(changed, removed)
+	want := []string{"a.zip", "a.zip/three.pdf", "a.zip/raw.txt", "b.pdf"}
+	if !reflect.DeepEqual(fileNames(project.Files), want) {
+		t.Errorf("Files = %v, want %v", fileNames(project.Files), want)
+	}
+
+	project.UpdateFiles(nil, []string{"a.zip"})
+	if want := []string{"b.pdf"}; !reflect.DeepEqual(fileNames(project.Files), want) {
+		t.Errorf("Files = %v after removing the archive, want %v", fileNames(project.Files), want)
+	}
+}
@@ -0,0 +1,19 @@
+package iul
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func birthTime(filePath string, _ os.FileInfo) (time.Time, bool) {
+	var statx unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, filePath, 0, unix.STATX_BTIME, &statx); err != nil {
+		return time.Time{}, false
+	}
+	if statx.Mask&unix.STATX_BTIME == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(statx.Btime.Sec, int64(statx.Btime.Nsec)), true
+}
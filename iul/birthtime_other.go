@@ -0,0 +1,12 @@
+//go:build !linux && !windows && !darwin
+
+package iul
+
+import (
+	"os"
+	"time"
+)
+
+func birthTime(string, os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
@@ -0,0 +1,204 @@
+package iul
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// ChecksumAlgorithm names the digest stored in the checksum cache. Entries
+	// computed with another algorithm are never served.
+	ChecksumAlgorithm = "crc32-ieee"
+	cacheDirName      = "jubilant-spork"
+	cacheFileName     = "checksums.json"
+)
+
+type cacheEntry struct {
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"modTime"`
+	Algorithm string    `json:"algorithm"`
+	Checksum  string    `json:"checksum"`
+}
+
+type cacheFile struct {
+	Algorithm string                `json:"algorithm"`
+	Entries   map[string]cacheEntry `json:"entries"`
+}
+
+// ChecksumCache remembers the checksums of files by absolute path, size and
+// modification time so that unchanged files are not read again. A nil cache
+// is valid and never hits.
+type ChecksumCache struct {
+	path    string
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+	seen    map[string]bool
+	dirty   bool
+}
+
+// DefaultChecksumCachePath returns the cache location in the user cache dir.
+func DefaultChecksumCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cacheDirName, cacheFileName), nil
+}
+
+// OpenChecksumCache loads the cache stored at path. A missing or unreadable
+// cache file, or one written for another algorithm, gives an empty cache.
+func OpenChecksumCache(path string) (*ChecksumCache, error) {
+	cache := &ChecksumCache{path: path, entries: make(map[string]cacheEntry), seen: make(map[string]bool)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored cacheFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		log.Printf("Failed to read checksum cache %s due to %s", path, err)
+		return cache, nil
+	}
+	if stored.Algorithm != ChecksumAlgorithm {
+		cache.dirty = true
+		return cache, nil
+	}
+	for filePath, entry := range stored.Entries {
+		if entry.Algorithm == ChecksumAlgorithm {
+			cache.entries[filePath] = entry
+		}
+	}
+	return cache, nil
+}
+
+// absolutePath is the key files and project folders are stored under.
+func absolutePath(filePath string) string {
+	result, err := filepath.Abs(filePath)
+	if err != nil {
+		return filepath.Clean(filePath)
+	}
+	return result
+}
+
+// Lookup returns the cached checksum when the file still has the recorded
+// size and modification time.
+func (c *ChecksumCache) Lookup(filePath string, info fs.FileInfo) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := absolutePath(filePath)
+	c.seen[key] = true
+	entry, ok := c.entries[key]
+	if !ok || entry.Algorithm != ChecksumAlgorithm || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) {
+		return "", false
+	}
+	return entry.Checksum, true
+}
+
+func (c *ChecksumCache) Store(filePath string, file CheckedFile) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := absolutePath(filePath)
+	c.seen[key] = true
+	c.entries[key] = cacheEntry{
+		Size:      file.FileSize,
+		ModTime:   file.ModTime,
+		Algorithm: ChecksumAlgorithm,
+		Checksum:  file.Checksum,
+	}
+	c.dirty = true
+}
+
+// Save writes the cache back to disk if it changed since it was opened.
+// Entries of files not looked up since then are dropped when the file is gone
+// or changed, so the cache does not keep every file it ever saw.
+func (c *ChecksumCache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.prune()
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(cacheFile{Algorithm: ChecksumAlgorithm, Entries: c.entries})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	temporaryPath := c.path + ".tmp"
+	if err := os.WriteFile(temporaryPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(temporaryPath, c.path); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// prune drops the entries of files that were not seen and no longer stat
+// with the recorded size and modification time.
+func (c *ChecksumCache) prune() {
+	for filePath, entry := range c.entries {
+		if c.seen[filePath] {
+			continue
+		}
+		info, err := os.Stat(filePath)
+		if err != nil || info.Size() != entry.Size || !info.ModTime().Equal(entry.ModTime) {
+			delete(c.entries, filePath)
+			c.dirty = true
+		}
+	}
+}
+
+// cachedChecksumFile is ChecksumFile served from options.Cache when possible.
+func cachedChecksumFile(ctx context.Context, dir string, fileName string, options ScanOptions) (CheckedFile, error) {
+	if options.Cache == nil {
+		return ChecksumFile(ctx, dir, fileName, options.Timestamps)
+	}
+	if err := ctx.Err(); err != nil {
+		return CheckedFile{}, err
+	}
+	filePath := filepath.Join(dir, fileName)
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return CheckedFile{}, err
+	}
+	if !options.ForceRehash {
+		if checksum, ok := options.Cache.Lookup(filePath, info); ok {
+			createdAt, timestampSource := resolveTimestamp(filePath, info, options.Timestamps)
+			return CheckedFile{
+				FileName:        fileName,
+				Checksum:        checksum,
+				FileSize:        info.Size(),
+				CreatedAt:       createdAt,
+				TimestampSource: timestampSource,
+				ModTime:         info.ModTime(),
+			}, nil
+		}
+	}
+	checkedFile, err := ChecksumFile(ctx, dir, fileName, options.Timestamps)
+	if err != nil {
+		return CheckedFile{}, err
+	}
+	options.Cache.Store(filePath, checkedFile)
+	return checkedFile, nil
+}
@@ -0,0 +1,162 @@
+package iul
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestChecksumCache(t *testing.T) {
+	dir := t.TempDir()
+	filePath := writeFile(t, dir, "a.pdf", "hello")
+	cachePath := filepath.Join(t.TempDir(), "cache", cacheFileName)
+	cache, err := OpenChecksumCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := ScanOptions{Cache: cache}
+	files, err := Scan(context.Background(), dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reopened cache serves the recorded checksum without reading the file,
+	// which the fake digest makes visible.
+	fake := files[0]
+	fake.Checksum = "CACHED"
+	cache.Store(filePath, fake)
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	cache, err = OpenChecksumCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.Cache = cache
+	files, err = Scan(context.Background(), dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].Checksum != "CACHED" || files[0].FileSize != 5 {
+		t.Errorf("Scan() = %+v, want the cached checksum", files[0])
+	}
+
+	options.ForceRehash = true
+	files, err = Scan(context.Background(), dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].Checksum != "3610A686" {
+		t.Errorf("Scan(ForceRehash) checksum = %s, want 3610A686", files[0].Checksum)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checksum, ok := cache.Lookup(filePath, info); !ok || checksum != "3610A686" {
+		t.Errorf("Lookup() after ForceRehash = %s, %v", checksum, ok)
+	}
+}
+
+func TestChecksumCacheInvalidation(t *testing.T) {
+	dir := t.TempDir()
+	filePath := writeFile(t, dir, "a.pdf", "hello")
+	cache, err := OpenChecksumCache(filepath.Join(t.TempDir(), cacheFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := cachedChecksumFile(context.Background(), dir, "a.pdf", ScanOptions{Cache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	touched := file.ModTime.Add(time.Minute)
+	if err := os.Chtimes(filePath, touched, touched); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Lookup(filePath, info); ok {
+		t.Error("Lookup() hit after the modification time changed")
+	}
+
+	cache.entries[absolutePath(filePath)] = cacheEntry{Size: info.Size(), ModTime: info.ModTime(), Algorithm: "md5", Checksum: "OLD"}
+	if _, ok := cache.Lookup(filePath, info); ok {
+		t.Error("Lookup() served an entry of another algorithm")
+	}
+}
+
+func TestChecksumCachePrunesMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	otherDir := t.TempDir()
+	writeFile(t, dir, "a.pdf", "a")
+	removed := writeFile(t, dir, "b.pdf", "b")
+	writeFile(t, otherDir, "c.pdf", "c")
+	cachePath := filepath.Join(t.TempDir(), cacheFileName)
+	cache, err := OpenChecksumCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scanDir := range []string{dir, otherDir} {
+		if _, err := Scan(context.Background(), scanDir, ScanOptions{Cache: cache}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file of another project stays cached while it is unchanged.
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+	cache, err = OpenChecksumCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Scan(context.Background(), dir, ScanOptions{Cache: cache}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	cache, err = OpenChecksumCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filePath := range []string{filepath.Join(dir, "a.pdf"), filepath.Join(otherDir, "c.pdf")} {
+		if _, ok := cache.entries[filePath]; !ok {
+			t.Errorf("Save() dropped %s", filePath)
+		}
+	}
+	if _, ok := cache.entries[removed]; ok {
+		t.Errorf("Save() kept the removed %s", removed)
+	}
+}
+
+func TestOpenChecksumCacheDropsOtherAlgorithm(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), cacheFileName)
+	data := `{"algorithm":"md5","entries":{"/a.pdf":{"size":1,"algorithm":"md5","checksum":"X"}}}`
+	if err := os.WriteFile(cachePath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := OpenChecksumCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("OpenChecksumCache() kept %d entries of another algorithm", len(cache.entries))
+	}
+
+	var nilCache *ChecksumCache
+	if err := nilCache.Save(); err != nil {
+		t.Errorf("nil cache Save() = %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package iul
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type CheckedFile struct {
+	FileName        string          `json:"fileName"`
+	Checksum        string          `json:"checksum"`
+	FileSize        int64           `json:"fileSize"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	TimestampSource TimestampSource `json:"timestampSource"`
+	ModTime         time.Time       `json:"modTime"`
+	// Archive is the name of the archive the file was read from, if any.
+	Archive string `json:"archive,omitempty"`
+}
+
+// contextReader stops a long copy as soon as the context is cancelled.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// ChecksumFile computes the CRC32 checksum, size and timestamp of dir/fileName.
+func ChecksumFile(ctx context.Context, dir string, fileName string, timestamps TimestampOptions) (CheckedFile, error) {
+	filePath := filepath.Join(dir, fileName)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return CheckedFile{}, err
+	}
+
+	defer func() {
+		closeErr := file.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	hasher := crc32.NewIEEE()
+	if _, err = io.Copy(hasher, contextReader{ctx: ctx, reader: file}); err != nil {
+		return CheckedFile{}, err
+	}
+	checksum := hasher.Sum32()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return CheckedFile{}, err
+	}
+
+	createdAt, timestampSource := resolveTimestamp(filePath, fileInfo, timestamps)
+
+	return CheckedFile{
+		FileName:        fileName,
+		Checksum:        formatChecksum(checksum),
+		FileSize:        fileInfo.Size(),
+		CreatedAt:       createdAt,
+		TimestampSource: timestampSource,
+		ModTime:         fileInfo.ModTime(),
+	}, err
+}
+
+func formatChecksum(checksum uint32) string {
+	return strings.ToUpper(fmt.Sprintf("%x", checksum))
+}
+
+// ChecksumPath is ChecksumFile for a full file path.
+func ChecksumPath(ctx context.Context, filePath string, timestamps TimestampOptions) (CheckedFile, error) {
+	return ChecksumFile(ctx, filepath.Dir(filePath), filepath.Base(filePath), timestamps)
+}
@@ -0,0 +1,128 @@
+package iul
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	filePath := filepath.Join(dir, name)
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
+
+func TestChecksumFile(t *testing.T) {
+	dir := t.TempDir()
+	filePath := writeFile(t, dir, "hello.txt", "hello")
+	modTime := time.Date(2024, 3, 1, 10, 30, 0, 0, time.Local)
+	if err := os.Chtimes(filePath, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := ChecksumFile(context.Background(), dir, "hello.txt", TimestampOptions{Source: TimestampModified})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.FileName != "hello.txt" {
+		t.Errorf("FileName = %q, want hello.txt", file.FileName)
+	}
+	if file.Checksum != "3610A686" {
+		t.Errorf("Checksum = %q, want 3610A686", file.Checksum)
+	}
+	if file.FileSize != 5 {
+		t.Errorf("FileSize = %d, want 5", file.FileSize)
+	}
+	if !file.CreatedAt.Equal(modTime) || file.TimestampSource != TimestampModified {
+		t.Errorf("CreatedAt = %v (%s), want %v (modified)", file.CreatedAt, file.TimestampSource, modTime)
+	}
+}
+
+func TestChecksumFileIssueDate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.txt", "a")
+	issueDate := time.Date(2023, 12, 25, 0, 0, 0, 0, time.Local)
+
+	file, err := ChecksumFile(context.Background(), dir, "a.txt", TimestampOptions{Source: TimestampIssueDate, IssueDate: issueDate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !file.CreatedAt.Equal(issueDate) || file.TimestampSource != TimestampIssueDate {
+		t.Errorf("CreatedAt = %v (%s), want issue date", file.CreatedAt, file.TimestampSource)
+	}
+
+	file, err = ChecksumFile(context.Background(), dir, "a.txt", TimestampOptions{Source: TimestampIssueDate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.TimestampSource != TimestampModified {
+		t.Errorf("TimestampSource = %s without an issue date, want fallback to modified", file.TimestampSource)
+	}
+}
+
+func TestChecksumFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ChecksumFile(context.Background(), dir, "missing.txt", TimestampOptions{}); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ChecksumFile(missing) error = %v, want ErrNotExist", err)
+	}
+
+	writeFile(t, dir, "a.txt", "a")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ChecksumFile(ctx, dir, "a.txt", TimestampOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ChecksumFile(cancelled) error = %v, want context.Canceled", err)
+	}
+}
+
+func TestScanExcludesOutput(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.pdf", "a")
+	writeFile(t, dir, "b.pdf", "b")
+	output := writeFile(t, dir, "result.docx", "output")
+	writeFile(t, dir, "result"+ManifestSuffix, "{}")
+
+	files, err := Scan(context.Background(), dir, ScanOptions{Exclude: []string{output, ManifestPath(output)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, file.FileName)
+	}
+	if len(names) != 2 || names[0] != "a.pdf" || names[1] != "b.pdf" {
+		t.Errorf("Scan() = %v, want [a.pdf b.pdf]", names)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.pdf", "a")
+	writeFile(t, dir, "b.pdf", "b")
+	files, err := Scan(context.Background(), dir, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mismatches, err := Verify(context.Background(), dir, files)
+	if err != nil || len(mismatches) != 0 {
+		t.Fatalf("Verify() = %v, %v on an unchanged folder", mismatches, err)
+	}
+
+	writeFile(t, dir, "a.pdf", "changed")
+	if err := os.Remove(filepath.Join(dir, "b.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	mismatches, err = Verify(context.Background(), dir, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 2 || mismatches[0].FileName != "a.pdf" || mismatches[0].Missing || !mismatches[1].Missing {
+		t.Errorf("Verify() = %+v, want a.pdf changed and b.pdf missing", mismatches)
+	}
+}
@@ -0,0 +1,41 @@
+package iul
+
+import (
+	"slices"
+)
+
+type fileIdentity struct {
+	checksum string
+	size     int64
+}
+
+// Duplicates maps every file with the same checksum and size as an earlier
+// file of the list to the name of that earlier file. Archive entries are not
+// compared, they can only be listed or dropped with their archive.
+func Duplicates(files []CheckedFile) map[string]string {
+	originals := make(map[fileIdentity]string)
+	duplicates := make(map[string]string)
+	for _, file := range files {
+		if file.Archive != "" {
+			continue
+		}
+		identity := fileIdentity{checksum: file.Checksum, size: file.FileSize}
+		if original, ok := originals[identity]; ok {
+			duplicates[file.FileName] = original
+			continue
+		}
+		originals[identity] = file.FileName
+	}
+	return duplicates
+}
+
+// RemoveDuplicates keeps the first of every group of duplicate files. The
+// entries of a removed archive are removed as well.
+func RemoveDuplicates(files []CheckedFile) []CheckedFile {
+	duplicates := Duplicates(files)
+	return slices.DeleteFunc(slices.Clone(files), func(file CheckedFile) bool {
+		_, duplicate := duplicates[file.FileName]
+		_, archiveDuplicate := duplicates[file.Archive]
+		return duplicate || archiveDuplicate
+	})
+}
@@ -0,0 +1,35 @@
+package iul
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	files := []CheckedFile{
+		{FileName: "a.pdf", Checksum: "1", FileSize: 10},
+		{FileName: "b.pdf", Checksum: "2", FileSize: 10},
+		{FileName: "copy of a.pdf", Checksum: "1", FileSize: 10},
+		{FileName: "c.pdf", Checksum: "1", FileSize: 11},
+		{FileName: "a.zip", Checksum: "3", FileSize: 5},
+		{FileName: "a.zip/a.pdf", Archive: "a.zip", Checksum: "1", FileSize: 10},
+		{FileName: "b.zip", Checksum: "3", FileSize: 5},
+		{FileName: "b.zip/a.pdf", Archive: "b.zip", Checksum: "1", FileSize: 10},
+	}
+	want := map[string]string{"copy of a.pdf": "a.pdf", "b.zip": "a.zip"}
+	if got := Duplicates(files); !reflect.DeepEqual(got, want) {
+		t.Errorf("Duplicates() = %v, want %v", got, want)
+	}
+	wantNames := []string{"a.pdf", "b.pdf", "c.pdf", "a.zip", "a.zip/a.pdf"}
+	if got := fileNames(RemoveDuplicates(files)); !reflect.DeepEqual(got, wantNames) {
+		t.Errorf("RemoveDuplicates() = %v, want %v", got, wantNames)
+	}
+	if len(files) != 8 {
+		t.Error("RemoveDuplicates() changed its argument")
+	}
+
+	problems := Validate(&Project{Files: files}, nil, ValidationRules{Severities: map[string]Severity{RuleDuplicateFile: SeverityWarning}})
+	if got := problemKeys(problems); !reflect.DeepEqual(got, []string{"warning duplicate_file copy of a.pdf", "warning duplicate_file b.zip"}) {
+		t.Errorf("Validate() = %q", got)
+	}
+}
@@ -0,0 +1,257 @@
+package iul
+
+import (
+	"context"
+	"github.com/xuri/excelize/v2"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CONTROL_SHEET_NAME = "Лист управления"
+	AUTHOR_SHEET_NAME  = "Содержание"
+)
+
+var authorStartCells = []string{"D5", "F5"}
+
+type CellRange struct {
+	startRow int
+	startCol int
+	endRow   int
+	endCol   int
+}
+
+// xlsxWorkbook reads Office Open XML workbooks with excelize.
+type xlsxWorkbook struct {
+	file *excelize.File
+}
+
+func (w xlsxWorkbook) Sheet(sheet string, options WorkbookOptions) (ControlSheet, error) {
+	file := w.file
+	rows, err := file.GetRows(sheet, excelize.Options{RawCellValue: options.RawValues})
+	if err != nil {
+		return ControlSheet{}, err
+	}
+	control := ControlSheet{Rows: rows, Merged: extractMergedCells(file, sheet)}
+	var date1904 bool
+	if props, err := file.GetWorkbookProps(); err == nil && props.Date1904 != nil {
+		date1904 = *props.Date1904
+	}
+	dateStyles := make(map[int]bool)
+	rowCount, colCount := sheetSize(file, sheet, control)
+	for row := range rowCount {
+		for col := range colCount {
+			name := CellName(row, col)
+			formula, _ := file.GetCellFormula(sheet, name)
+			if formula != "" && (options.Recalculate || control.Value(row, col) == "") {
+				value, err := file.CalcCellValue(sheet, name, excelize.Options{RawCellValue: options.RawValues})
+				if err != nil {
+					log.Printf("Failed to calculate %s due to %s", name, err)
+				} else {
+					control.Rows = setCellValue(control.Rows, row, col, value)
+				}
+			}
+			if date, ok := cellDate(file, sheet, name, formula != "", date1904, dateStyles); ok {
+				control.setDate(name, date)
+			}
+		}
+	}
+	return control, nil
+}
+
+func (w xlsxWorkbook) Close() error {
+	return w.file.Close()
+}
+
+func extractControlData(workbook Workbook, options WorkbookOptions) ControlSheet {
+	control, err := workbook.Sheet(CONTROL_SHEET_NAME, options)
+	if err != nil {
+		log.Printf("Failed to get rows from %s due to %s", CONTROL_SHEET_NAME, err)
+		return ControlSheet{}
+	}
+	return control
+}
+
+// sheetSize returns the number of rows and columns of the sheet, including
+// the trailing formula cells without a cached value that GetRows leaves out.
+func sheetSize(file *excelize.File, sheet string, control ControlSheet) (rows int, cols int) {
+	rows, cols = control.RowCount(), control.ColumnCount()
+	dimension, err := file.GetSheetDimension(sheet)
+	if err != nil {
+		return rows, cols
+	}
+	_, end, _ := strings.Cut(dimension, ":")
+	if end == "" {
+		end = dimension
+	}
+	endCol, endRow, err := excelize.CellNameToCoordinates(end)
+	if err != nil {
+		return rows, cols
+	}
+	return max(rows, endRow), max(cols, endCol)
+}
+
+func setCellValue(rows [][]string, row, col int, value string) [][]string {
+	for len(rows) <= row {
+		rows = append(rows, nil)
+	}
+	for len(rows[row]) <= col {
+		rows[row] = append(rows[row], "")
+	}
+	rows[row][col] = value
+	return rows
+}
+
+// dateNumberFormats are the built-in number formats of dates.
+var dateNumberFormats = []int{14, 15, 16, 17, 22}
+
+// rxNumberFormatLiteral matches the quoted text, escaped characters and
+// [colour], [$-locale] sections of a number format.
+var rxNumberFormatLiteral = regexp.MustCompile(`"[^"]*"|\\.|\[[^\]]*\]`)
+
+func isDateFormat(numFmt int, customNumFmt *string) bool {
+	if customNumFmt == nil {
+		return slices.Contains(dateNumberFormats, numFmt)
+	}
+	format := strings.ToLower(rxNumberFormatLiteral.ReplaceAllString(*customNumFmt, ""))
+	return strings.ContainsAny(format, "dy")
+}
+
+// cellDate returns the date stored in a cell with a date number format.
+// dateStyles caches whether a style index is a date format.
+func cellDate(file *excelize.File, sheet string, name string, formula bool, date1904 bool, dateStyles map[int]bool) (time.Time, bool) {
+	styleIndex, err := file.GetCellStyle(sheet, name)
+	if err != nil || styleIndex == 0 {
+		return time.Time{}, false
+	}
+	isDate, ok := dateStyles[styleIndex]
+	if !ok {
+		style, err := file.GetStyle(styleIndex)
+		isDate = err == nil && isDateFormat(style.NumFmt, style.CustomNumFmt)
+		dateStyles[styleIndex] = isDate
+	}
+	if !isDate {
+		return time.Time{}, false
+	}
+	raw, _ := file.GetCellValue(sheet, name, excelize.Options{RawCellValue: true})
+	if raw == "" && formula {
+		raw, _ = file.CalcCellValue(sheet, name, excelize.Options{RawCellValue: true})
+	}
+	serial, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	date, err := excelize.ExcelDateToTime(serial, date1904)
+	return date, err == nil
+}
+
+func extractMergedCells(file *excelize.File, sheet string) []MergedCell {
+	mergeCells, err := file.GetMergeCells(sheet)
+	if err != nil {
+		log.Printf("Failed to get merged cells from %s due to %s", sheet, err)
+		return nil
+	}
+	var merged []MergedCell
+	for _, mergeCell := range mergeCells {
+		startCol, startRow, err := excelize.CellNameToCoordinates(mergeCell.GetStartAxis())
+		if err != nil {
+			continue
+		}
+		endCol, endRow, err := excelize.CellNameToCoordinates(mergeCell.GetEndAxis())
+		if err != nil {
+			continue
+		}
+		merged = append(merged, MergedCell{StartRow: startRow - 1, StartCol: startCol - 1, EndRow: endRow - 1, EndCol: endCol - 1})
+	}
+	return merged
+}
+
+func extractAuthorData(workbook Workbook) []Author {
+	sheet, err := workbook.Sheet(AUTHOR_SHEET_NAME, WorkbookOptions{})
+	if err != nil {
+		log.Printf("Failde to get rows from %s due to %s", AUTHOR_SHEET_NAME, err)
+	}
+	rows := sheet.Rows
+	var data []Author
+	var cellRangesFormatted []CellRange
+	for _, startCell := range authorStartCells {
+		startCol, startRow, err := excelize.CellNameToCoordinates(startCell)
+		if err != nil {
+			continue
+		}
+		cellRangesFormatted = append(
+			cellRangesFormatted,
+			CellRange{
+				startCol: startCol - 1,
+				startRow: startRow - 1,
+				endCol:   startCol,
+				endRow:   len(rows),
+			})
+	}
+	for _, cellRangeFormatted := range cellRangesFormatted {
+		if cellRangeFormatted.startRow >= cellRangeFormatted.endRow {
+			continue
+		}
+		for _, row := range rows[cellRangeFormatted.startRow:cellRangeFormatted.endRow] {
+			data = append(data, Author{
+				Title: cellValue(row, cellRangeFormatted.startCol),
+				Name:  cellValue(row, cellRangeFormatted.endCol),
+			})
+		}
+	}
+	return data
+}
+
+func cellValue(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return row[col]
+}
+
+// ReadWorkbook reads the control sheet and the authors from a workbook of one
+// of the WorkbookExtensions formats.
+func ReadWorkbook(ctx context.Context, path string, options WorkbookOptions) (ControlSheet, []Author, error) {
+	if err := ctx.Err(); err != nil {
+		return ControlSheet{}, nil, err
+	}
+	f, err := OpenWorkbook(path)
+	if err != nil {
+		return ControlSheet{}, nil, err
+	}
+	defer f.Close()
+
+	return extractControlData(f, options), extractAuthorData(f), nil
+}
+
+// SearchWorkbook walks dir looking for a workbook called name with one of the
+// WorkbookExtensions and returns the last match, or an empty string when there
+// is none.
+func SearchWorkbook(dir string, name string) string {
+	var foundFile string
+	err := filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			log.Println("Error:", err)
+			return err
+		}
+
+		ext := filepath.Ext(info.Name())
+		if !info.IsDir() && strings.TrimSuffix(info.Name(), ext) == name && slices.Contains(WorkbookExtensions, strings.ToLower(ext)) {
+			foundFile = path
+			log.Println("File found:", path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return ""
+	}
+
+	return foundFile
+}
@@ -0,0 +1,173 @@
+package iul
+
+import (
+	"context"
+	"github.com/xuri/excelize/v2"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newWorkbook(t *testing.T, sheets map[string]map[string]string) *excelize.File {
+	t.Helper()
+	f := excelize.NewFile()
+	t.Cleanup(func() { _ = f.Close() })
+	for sheet, cells := range sheets {
+		if _, err := f.NewSheet(sheet); err != nil {
+			t.Fatal(err)
+		}
+		for cell, value := range cells {
+			if err := f.SetCellValue(sheet, cell, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return f
+}
+
+func TestExtractAuthorData(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells map[string]map[string]string
+		want  []Author
+	}{
+		{
+			name: "both columns",
+			cells: map[string]map[string]string{AUTHOR_SHEET_NAME: {
+				"D5": "Разраб.", "E5": "Иванов",
+				"D6": "Разраб.", "E6": "Петров",
+				"F5": "Проверил", "G5": "Сидоров",
+			}},
+			want: []Author{
+				{Title: "Разраб.", Name: "Иванов"},
+				{Title: "Разраб.", Name: "Петров"},
+				{Title: "Проверил", Name: "Сидоров"},
+				{Title: "", Name: ""},
+			},
+		},
+		{
+			name: "short rows",
+			cells: map[string]map[string]string{AUTHOR_SHEET_NAME: {
+				"E5": "Иванов",
+				"A6": "x",
+			}},
+			want: []Author{
+				{Title: "", Name: "Иванов"},
+				{Title: "", Name: ""},
+				{Title: "", Name: ""},
+				{Title: "", Name: ""},
+			},
+		},
+		{
+			name:  "rows end before start cell",
+			cells: map[string]map[string]string{AUTHOR_SHEET_NAME: {"A1": "Заголовок", "E3": "Иванов"}},
+			want:  nil,
+		},
+		{
+			name:  "missing sheet",
+			cells: map[string]map[string]string{CONTROL_SHEET_NAME: {"A1": "x"}},
+			want:  nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := extractAuthorData(xlsxWorkbook{file: newWorkbook(t, test.cells)})
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("extractAuthorData() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadWorkbook(t *testing.T) {
+	f := newWorkbook(t, map[string]map[string]string{
+		CONTROL_SHEET_NAME: {"A1": "Шифр", "F7": "123-ИУЛ"},
+		AUTHOR_SHEET_NAME:  {"D5": "ГИП", "E5": "Иванов"},
+	})
+	if err := f.MergeCell(CONTROL_SHEET_NAME, "A1", "C2"); err != nil {
+		t.Fatal(err)
+	}
+	workbookPath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := f.SaveAs(workbookPath); err != nil {
+		t.Fatal(err)
+	}
+
+	control, authors, err := ReadWorkbook(context.Background(), workbookPath, WorkbookOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := control.Cells()["F7"]; got != "123-ИУЛ" {
+		t.Errorf("control F7 = %q, want 123-ИУЛ", got)
+	}
+	if want := []MergedCell{{StartRow: 0, StartCol: 0, EndRow: 1, EndCol: 2}}; !reflect.DeepEqual(control.Merged, want) {
+		t.Errorf("control merged = %+v, want %+v", control.Merged, want)
+	}
+	if len(authors) == 0 || authors[0] != (Author{Title: "ГИП", Name: "Иванов"}) {
+		t.Errorf("authors = %v, want ГИП Иванов first", authors)
+	}
+
+	if _, _, err := ReadWorkbook(context.Background(), filepath.Join(t.TempDir(), "missing.xlsx"), WorkbookOptions{}); err == nil {
+		t.Error("ReadWorkbook(missing) returned no error")
+	}
+}
+
+func TestReadWorkbookValues(t *testing.T) {
+	f := newWorkbook(t, map[string]map[string]string{
+		CONTROL_SHEET_NAME: {"A1": "Дата", "C1": "Итого"},
+		"Данные":           {"A1": "12"},
+	})
+	set := func(cell string, value any) {
+		if err := f.SetCellValue(CONTROL_SHEET_NAME, cell, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	style := func(cells string, format *string, numFmt int) {
+		id, err := f.NewStyle(&excelize.Style{NumFmt: numFmt, CustomNumFmt: format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.SetCellStyle(CONTROL_SHEET_NAME, cells, cells, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	set("B1", time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC))
+	customDate := "dd.mm.yyyy"
+	style("B1", &customDate, 0)
+	set("B2", 0.25)
+	style("B2", nil, 9)
+	if err := f.SetCellFormula(CONTROL_SHEET_NAME, "D1", "Данные!A1*2"); err != nil {
+		t.Fatal(err)
+	}
+	workbookPath := filepath.Join(t.TempDir(), "book.xlsx")
+	if err := f.SaveAs(workbookPath); err != nil {
+		t.Fatal(err)
+	}
+
+	control, _, err := ReadWorkbook(context.Background(), workbookPath, WorkbookOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := control.Cells()
+	if cells["B1"] != "05.03.2024" || cells["B2"] != "25%" || cells["D1"] != "24" {
+		t.Errorf("displayed B1, B2, D1 = %q, %q, %q, want 05.03.2024, 25%%, 24", cells["B1"], cells["B2"], cells["D1"])
+	}
+	if want := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC); !control.Dates["B1"].Equal(want) || len(control.Dates) != 1 {
+		t.Errorf("dates = %v, want B1 %v", control.Dates, want)
+	}
+	if got := FindFormatProfile("iso8601").FormatControl(control)["B1"]; got != "2024-03-05" {
+		t.Errorf("FormatControl() B1 = %q, want 2024-03-05", got)
+	}
+
+	control, _, err = ReadWorkbook(context.Background(), workbookPath, WorkbookOptions{RawValues: true, Recalculate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells = control.Cells()
+	if cells["B1"] != "45356" || cells["B2"] != "0.25" || cells["D1"] != "24" {
+		t.Errorf("raw B1, B2, D1 = %q, %q, %q, want 45356, 0.25, 24", cells["B1"], cells["B2"], cells["D1"])
+	}
+	if _, ok := control.Dates["B1"]; !ok {
+		t.Error("raw values lost the B1 date")
+	}
+}
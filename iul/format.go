@@ -0,0 +1,176 @@
+package iul
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultDateLayout     = "2006.01.02_15:04"
+	DefaultCellDateLayout = "02.01.2006"
+	DefaultFormatProfile  = "default"
+	humanSizeBase         = 1024
+)
+
+type SizeFormat int
+
+const (
+	SizeBytes SizeFormat = iota
+	SizeGrouped
+	SizeHuman
+)
+
+// NumberLocale holds the locale specific parts of number formatting.
+type NumberLocale struct {
+	GroupSeparator   string
+	DecimalSeparator string
+	// SizeUnits are the byte, kilobyte, megabyte... unit names.
+	SizeUnits []string
+}
+
+var DefaultNumberLocale = NumberLocale{
+	GroupSeparator:   " ",
+	DecimalSeparator: ",",
+	SizeUnits:        []string{"Б", "КБ", "МБ", "ГБ", "ТБ"},
+}
+
+// FormatProfile describes how typed file values are turned into the strings
+// shown in the tables and written into the document.
+type FormatProfile struct {
+	Name       string
+	DateLayout string
+	// CellDateLayout formats the control sheet dates without a time of day.
+	CellDateLayout string
+	SizeFormat     SizeFormat
+	Locale         NumberLocale
+}
+
+var FormatProfiles = []FormatProfile{
+	{Name: DefaultFormatProfile, DateLayout: DefaultDateLayout, CellDateLayout: DefaultCellDateLayout, SizeFormat: SizeBytes},
+	{Name: "dotted", DateLayout: "02.01.2006 15:04", CellDateLayout: DefaultCellDateLayout, SizeFormat: SizeBytes},
+	{Name: "seconds", DateLayout: "02.01.2006 15:04:05", CellDateLayout: DefaultCellDateLayout, SizeFormat: SizeBytes},
+	{Name: "iso8601", DateLayout: time.RFC3339, CellDateLayout: time.DateOnly, SizeFormat: SizeBytes},
+	{Name: "grouped", DateLayout: "02.01.2006 15:04", CellDateLayout: DefaultCellDateLayout, SizeFormat: SizeGrouped},
+	{Name: "human", DateLayout: "02.01.2006 15:04", CellDateLayout: DefaultCellDateLayout, SizeFormat: SizeHuman},
+}
+
+// FindFormatProfile returns the named profile with the default number locale,
+// or the first profile when the name is unknown.
+func FindFormatProfile(name string) FormatProfile {
+	profile := FormatProfiles[0]
+	for _, formatProfile := range FormatProfiles {
+		if formatProfile.Name == name {
+			profile = formatProfile
+		}
+	}
+	profile.Locale = DefaultNumberLocale
+	return profile
+}
+
+func (p FormatProfile) FormatDate(value time.Time) string {
+	if value.IsZero() {
+		return ""
+	}
+	return value.Format(p.DateLayout)
+}
+
+// formatCellDate formats a control sheet date; dates with a time of day are
+// formatted as file dates.
+func (p FormatProfile) formatCellDate(date time.Time) string {
+	if date.Hour() != 0 || date.Minute() != 0 || date.Second() != 0 {
+		return p.FormatDate(date)
+	}
+	if p.CellDateLayout == "" {
+		return date.Format(DefaultCellDateLayout)
+	}
+	return date.Format(p.CellDateLayout)
+}
+
+// FormatCell returns the value of a control sheet cell, dates formatted with
+// the profile.
+func (p FormatProfile) FormatCell(control ControlSheet, row, col int) string {
+	if date, ok := control.Dates[CellName(row, col)]; ok {
+		return p.formatCellDate(date)
+	}
+	return control.Value(row, col)
+}
+
+// FormatControl maps the control sheet cell names to their values, dates
+// formatted with the profile.
+func (p FormatProfile) FormatControl(control ControlSheet) map[string]string {
+	cells := control.Cells()
+	for name, date := range control.Dates {
+		cells[name] = p.formatCellDate(date)
+	}
+	return cells
+}
+
+func (p FormatProfile) FormatSize(size int64) string {
+	switch p.SizeFormat {
+	case SizeGrouped:
+		return groupDigits(strconv.FormatInt(size, 10), p.locale().GroupSeparator)
+	case SizeHuman:
+		return humanSize(size, p.locale())
+	default:
+		return strconv.FormatInt(size, 10)
+	}
+}
+
+func groupDigits(digits string, separator string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	var builder strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			builder.WriteString(separator)
+		}
+		builder.WriteRune(digit)
+	}
+	return sign + builder.String()
+}
+
+func (p FormatProfile) locale() NumberLocale {
+	if len(p.Locale.SizeUnits) == 0 {
+		return DefaultNumberLocale
+	}
+	return p.Locale
+}
+
+func humanSize(size int64, locale NumberLocale) string {
+	value := float64(size)
+	unit := 0
+	for value >= humanSizeBase && unit < len(locale.SizeUnits)-1 {
+		value /= humanSizeBase
+		unit++
+	}
+	if unit == 0 {
+		return strconv.FormatInt(size, 10) + " " + locale.SizeUnits[unit]
+	}
+	formatted := strings.Replace(strconv.FormatFloat(value, 'f', 1, 64), ".", locale.DecimalSeparator, 1)
+	return formatted + " " + locale.SizeUnits[unit]
+}
+
+// ItemRow is the formatted representation of a CheckedFile used by templates.
+type ItemRow struct {
+	FileName  string
+	Checksum  string
+	FileSize  string
+	CreatedAt string
+	// Status is filled when the files are compared with a previous issue.
+	Status string
+}
+
+func (p FormatProfile) FormatFile(file CheckedFile) ItemRow {
+	if file.FileName == "" {
+		return ItemRow{}
+	}
+	return ItemRow{
+		FileName:  file.FileName,
+		Checksum:  file.Checksum,
+		FileSize:  p.FormatSize(file.FileSize),
+		CreatedAt: p.FormatDate(file.CreatedAt),
+	}
+}
@@ -0,0 +1,44 @@
+package iul
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		profile string
+		size    int64
+		want    string
+	}{
+		{profile: "default", size: 1234567, want: "1234567"},
+		{profile: "grouped", size: 1234567, want: "1 234 567"},
+		{profile: "grouped", size: 123, want: "123"},
+		{profile: "grouped", size: -1234, want: "-1 234"},
+		{profile: "human", size: 512, want: "512 Б"},
+		{profile: "human", size: 1536, want: "1,5 КБ"},
+		{profile: "human", size: 5 * 1024 * 1024, want: "5,0 МБ"},
+	}
+	for _, test := range tests {
+		if got := FindFormatProfile(test.profile).FormatSize(test.size); got != test.want {
+			t.Errorf("%s FormatSize(%d) = %q, want %q", test.profile, test.size, got, test.want)
+		}
+	}
+}
+
+func TestFormatFile(t *testing.T) {
+	file := CheckedFile{
+		FileName:  "a.pdf",
+		Checksum:  "ABC",
+		FileSize:  2048,
+		CreatedAt: time.Date(2024, 3, 1, 10, 30, 15, 0, time.UTC),
+	}
+	got := FindFormatProfile("seconds").FormatFile(file)
+	want := ItemRow{FileName: "a.pdf", Checksum: "ABC", FileSize: "2048", CreatedAt: "01.03.2024 10:30:15"}
+	if got != want {
+		t.Errorf("FormatFile() = %+v, want %+v", got, want)
+	}
+	if got := FindFormatProfile("unknown").FormatFile(CheckedFile{}); got != (ItemRow{}) {
+		t.Errorf("FormatFile(empty) = %+v, want empty row", got)
+	}
+}
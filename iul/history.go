@@ -0,0 +1,131 @@
+package iul
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	historyDirName     = "history"
+	revisionFileFormat = "%04d" + ManifestSuffix
+)
+
+// Revision is a render recorded in the project history. Revisions are
+// numbered from 1 in the order they were rendered.
+type Revision struct {
+	Number      int
+	Path        string
+	GeneratedAt time.Time
+	Output      string
+}
+
+// FileChange is a file of the current list, or a removed file of the compared
+// revision, with its status. Previous is empty for new files.
+type FileChange struct {
+	File     CheckedFile
+	Previous CheckedFile
+	Status   FileStatus
+}
+
+func (s *ProjectStore) historyDir(folder string) string {
+	return filepath.Join(s.ProjectDir(folder), historyDirName)
+}
+
+// Revisions lists the history of the project folder, oldest first. A project
+// without history has no revisions.
+func (s *ProjectStore) Revisions(folder string) ([]Revision, error) {
+	dir := s.historyDir(folder)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var revisions []Revision
+	for _, entry := range entries {
+		var number int
+		if _, err := fmt.Sscanf(entry.Name(), revisionFileFormat, &number); err != nil || !strings.HasSuffix(entry.Name(), ManifestSuffix) {
+			continue
+		}
+		revisionPath := filepath.Join(dir, entry.Name())
+		manifest, err := LoadManifest(revisionPath)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, Revision{
+			Number:      number,
+			Path:        revisionPath,
+			GeneratedAt: manifest.GeneratedAt,
+			Output:      manifest.Output,
+		})
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Number < revisions[j].Number
+	})
+	return revisions, nil
+}
+
+// LatestRevision returns the manifest of the last render of the project
+// folder, or nil when there is no history yet.
+func (s *ProjectStore) LatestRevision(folder string) (*Manifest, error) {
+	revisions, err := s.Revisions(folder)
+	if err != nil || len(revisions) == 0 {
+		return nil, err
+	}
+	return LoadManifest(revisions[len(revisions)-1].Path)
+}
+
+// SaveRevision copies the manifest of a render into the project history as
+// the next revision.
+func (s *ProjectStore) SaveRevision(folder string, manifestPath string) (Revision, error) {
+	manifest, err := LoadManifest(manifestPath)
+	if err != nil {
+		return Revision{}, err
+	}
+	revisions, err := s.Revisions(folder)
+	if err != nil {
+		return Revision{}, err
+	}
+	revision := Revision{Number: 1, GeneratedAt: manifest.GeneratedAt, Output: manifest.Output}
+	if len(revisions) > 0 {
+		revision.Number = revisions[len(revisions)-1].Number + 1
+	}
+	dir := s.historyDir(folder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Revision{}, err
+	}
+	revision.Path = filepath.Join(dir, fmt.Sprintf(revisionFileFormat, revision.Number))
+	return revision, manifest.Save(revision.Path)
+}
+
+// CompareFiles gives the status of every current file against the previous
+// list, in the current order, followed by the files that were removed.
+func CompareFiles(previous []CheckedFile, current []CheckedFile) []FileChange {
+	previousFiles := make(map[string]CheckedFile, len(previous))
+	for _, file := range previous {
+		previousFiles[file.FileName] = file
+	}
+	currentNames := make(map[string]bool, len(current))
+	changes := make([]FileChange, 0, len(current))
+	for _, file := range current {
+		currentNames[file.FileName] = true
+		changes = append(changes, FileChange{
+			File:     file,
+			Previous: previousFiles[file.FileName],
+			Status:   fileStatus(previousFiles, file),
+		})
+	}
+	for _, file := range previous {
+		if !currentNames[file.FileName] {
+			changes = append(changes, FileChange{File: file, Previous: file, Status: StatusRemoved})
+		}
+	}
+	return changes
+}
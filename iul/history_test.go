@@ -0,0 +1,95 @@
+package iul
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCompareFiles(t *testing.T) {
+	previous := []CheckedFile{
+		{FileName: "a.pdf", Checksum: "1", FileSize: 1},
+		{FileName: "b.pdf", Checksum: "2", FileSize: 2},
+		{FileName: "c.pdf", Checksum: "3", FileSize: 3},
+	}
+	current := []CheckedFile{
+		{FileName: "d.pdf", Checksum: "4", FileSize: 4},
+		{FileName: "a.pdf", Checksum: "1", FileSize: 1},
+		{FileName: "b.pdf", Checksum: "5", FileSize: 2},
+	}
+	var got []string
+	for _, change := range CompareFiles(previous, current) {
+		got = append(got, change.File.FileName+":"+string(change.Status))
+	}
+	want := []string{"d.pdf:new", "a.pdf:unchanged", "b.pdf:changed", "c.pdf:removed"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestProjectHistory(t *testing.T) {
+	dir := t.TempDir()
+	store := NewProjectStore(t.TempDir())
+	folder := t.TempDir()
+
+	latest, err := store.LatestRevision(folder)
+	if err != nil || latest != nil {
+		t.Fatalf("LatestRevision() of a new project = %v, %v", latest, err)
+	}
+	first := writeTestManifest(t, dir)
+	if _, err := store.SaveRevision(folder, first); err != nil {
+		t.Fatal(err)
+	}
+	revision, err := store.SaveRevision(folder, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision.Number != 2 {
+		t.Errorf("second revision number = %d, want 2", revision.Number)
+	}
+	revisions, err := store.Revisions(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 2 || revisions[0].Number != 1 || revisions[1].Path != revision.Path {
+		t.Errorf("Revisions() = %+v", revisions)
+	}
+	latest, err = store.LatestRevision(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fileNames(latest.Files); !reflect.DeepEqual(got, []string{"a.pdf", "b.pdf"}) {
+		t.Errorf("latest revision files = %v", got)
+	}
+}
+
+func TestRenderDataStatus(t *testing.T) {
+	previous := &Manifest{Files: []CheckedFile{
+		{FileName: "a.pdf", Checksum: "1", FileSize: 1},
+		{FileName: "gone.pdf", Checksum: "2", FileSize: 2},
+	}}
+	project := &Project{Files: []CheckedFile{
+		{FileName: "a.pdf", Checksum: "1", FileSize: 1},
+		{FileName: "new.pdf", Checksum: "3", FileSize: 3},
+	}}
+	options := RenderOptions{Profile: FindFormatProfile(DefaultFormatProfile), Previous: previous}
+	renderData, _, err := NewRenderData(context.Background(), project, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renderData.Items[0].Status != "Без изменений" || renderData.Items[1].Status != "Новый" {
+		t.Errorf("item statuses = %q, %q", renderData.Items[0].Status, renderData.Items[1].Status)
+	}
+	if len(renderData.Removed) != 1 || renderData.Removed[0].FileName != "gone.pdf" || renderData.Removed[0].Status != "Удалён" {
+		t.Errorf("removed = %+v", renderData.Removed)
+	}
+
+	options.Previous = nil
+	renderData, _, err = NewRenderData(context.Background(), project, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renderData.Items[1].Status != "" || renderData.Removed != nil {
+		t.Errorf("statuses without a previous issue = %+v, %+v", renderData.Items, renderData.Removed)
+	}
+}
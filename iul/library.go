@@ -0,0 +1,152 @@
+package iul
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	TemplateExtension         = ".docx"
+	TemplateMetadataExtension = ".json"
+	templateLibraryDirName    = "templates"
+	documentPart              = "word/document.xml"
+)
+
+var (
+	rxXMLTag      = regexp.MustCompile(`<[^>]*>`)
+	rxPlaceholder = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+)
+
+// TemplateInfo describes a template of the library. Name, Description and
+// Fields come from the optional JSON file next to the template; without it the
+// name is the file name and the fields are read from the document.
+type TemplateInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Fields      []string `json:"fields,omitempty"`
+	Path        string   `json:"-"`
+}
+
+// DefaultTemplateLibraryDir returns the library location in the user config dir.
+func DefaultTemplateLibraryDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cacheDirName, templateLibraryDirName), nil
+}
+
+// LoadTemplateLibrary lists the templates of dir sorted by name. A missing
+// directory is an empty library.
+func LoadTemplateLibrary(dir string) ([]TemplateInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var templates []TemplateInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), TemplateExtension) || strings.HasPrefix(entry.Name(), "~$") {
+			continue
+		}
+		info, err := LoadTemplateInfo(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, info)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates, nil
+}
+
+// LoadTemplateInfo reads the metadata of a single template.
+func LoadTemplateInfo(templatePath string) (TemplateInfo, error) {
+	info := TemplateInfo{Path: templatePath}
+	metadataPath := strings.TrimSuffix(templatePath, filepath.Ext(templatePath)) + TemplateMetadataExtension
+	data, err := os.ReadFile(metadataPath)
+	if err == nil {
+		err = json.Unmarshal(data, &info)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return TemplateInfo{}, err
+	}
+	if info.Name == "" {
+		info.Name = strings.TrimSuffix(filepath.Base(templatePath), filepath.Ext(templatePath))
+	}
+	if len(info.Fields) == 0 {
+		info.Fields, err = TemplateFields(templatePath)
+		if err != nil {
+			return TemplateInfo{}, err
+		}
+	}
+	return info, nil
+}
+
+// templateDocument reads the document body part of the template, the built-in
+// template when the path is empty.
+func templateDocument(templatePath string) ([]byte, error) {
+	templatePath, err := resolveTemplatePath(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := zip.OpenReader(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	part, err := reader.Open(documentPart)
+	if err != nil {
+		return nil, err
+	}
+	defer part.Close()
+	return io.ReadAll(part)
+}
+
+// TemplateFields returns the sorted placeholder names used in the document
+// body, the only part Render fills: placeholders in headers and footers are
+// left as they are. An empty path reads the built-in template.
+func TemplateFields(templatePath string) ([]string, error) {
+	data, err := templateDocument(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var fields []string
+	text := rxXMLTag.ReplaceAllString(string(data), "")
+	for _, match := range rxPlaceholder.FindAllStringSubmatch(text, -1) {
+		field := match[1]
+		if strings.HasPrefix(field, "#") || strings.HasPrefix(field, "/") || seen[field] {
+			continue
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// ControlCells returns the control sheet cells (F7...) the template fields use,
+// in field order without repeats.
+func ControlCells(fields []string) []string {
+	var cells []string
+	for _, field := range fields {
+		cell, ok := strings.CutPrefix(field, outputControlPrefix)
+		if ok && !slices.Contains(cells, cell) {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
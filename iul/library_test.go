@@ -0,0 +1,115 @@
+package iul
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeDocx(t *testing.T, path string, parts map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	for name, content := range parts {
+		part, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTemplateFields(t *testing.T) {
+	fields, err := TemplateFields(testTemplatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Control.F7", "Items_FileName", "Authors_Name"} {
+		found := false
+		for _, field := range fields {
+			found = found || field == want
+		}
+		if !found {
+			t.Errorf("TemplateFields() = %v, missing %s", fields, want)
+		}
+	}
+
+	docxPath := filepath.Join(t.TempDir(), "split.docx")
+	writeDocx(t, docxPath, map[string]string{
+		"word/document.xml": `<w:p><w:r><w:t>{{Con</w:t></w:r><w:r><w:t>trol.A1}}</w:t></w:r><w:r><w:t>{{#each Items}}{{ Page }}</w:t></w:r></w:p>`,
+		"word/header1.xml":  `<w:hdr><w:t>{{Pages}}</w:t></w:hdr>`,
+		"word/footer1.xml":  `<w:ftr><w:t>{{Footer}}</w:t></w:ftr>`,
+	})
+	fields, err = TemplateFields(docxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Control.A1", "Page"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("TemplateFields() = %v, want %v", fields, want)
+	}
+}
+
+func TestControlCells(t *testing.T) {
+	fields := []string{"Authors_Name", "Control.F7", "Control.A1", "Control.F7"}
+	if got, want := ControlCells(fields), []string{"F7", "A1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ControlCells() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadTemplateLibrary(t *testing.T) {
+	dir := t.TempDir()
+	writeDocx(t, filepath.Join(dir, "form12.docx"), map[string]string{"word/document.xml": `<w:t>{{Control.F7}}</w:t>`})
+	writeFile(t, dir, "form12.json", `{"name": "ГОСТ Р 21.101 форма 12", "description": "Основная форма"}`)
+	writeDocx(t, filepath.Join(dir, "customer.docx"), map[string]string{"word/document.xml": `<w:t>{{Items_FileName}}</w:t>`})
+	writeFile(t, dir, "customer.json", `{"name": "Заказчик", "fields": ["Control.B2"]}`)
+	writeFile(t, dir, "notes.txt", "not a template")
+
+	templates, err := LoadTemplateLibrary(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TemplateInfo{
+		{Name: "ГОСТ Р 21.101 форма 12", Description: "Основная форма", Fields: []string{"Control.F7"}, Path: filepath.Join(dir, "form12.docx")},
+		{Name: "Заказчик", Fields: []string{"Control.B2"}, Path: filepath.Join(dir, "customer.docx")},
+	}
+	if !reflect.DeepEqual(templates, want) {
+		t.Errorf("LoadTemplateLibrary() = %+v, want %+v", templates, want)
+	}
+
+	if templates, err := LoadTemplateLibrary(filepath.Join(dir, "missing")); err != nil || templates != nil {
+		t.Errorf("LoadTemplateLibrary(missing) = %v, %v", templates, err)
+	}
+}
+
+func TestProjectStore(t *testing.T) {
+	store := NewProjectStore(t.TempDir())
+	settings, err := store.Load("/projects/a")
+	if err != nil || settings.Template != "" {
+		t.Fatalf("Load() of a new project = %+v, %v", settings, err)
+	}
+	settings.Template = "/templates/form12.docx"
+	if err := store.Save(settings); err != nil {
+		t.Fatal(err)
+	}
+	if other, err := store.Load("/projects/b"); err != nil || other.Template != "" {
+		t.Errorf("Load() of another project = %+v, %v", other, err)
+	}
+	loaded, err := store.Load("/projects/a/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != settings {
+		t.Errorf("Load() = %+v, want %+v", loaded, settings)
+	}
+}
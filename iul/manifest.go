@@ -0,0 +1,74 @@
+package iul
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const ManifestSuffix = ".manifest.json"
+
+// Manifest is the machine-readable record of a render, written next to the
+// output document. Values are stored raw, independent of the format profile.
+type Manifest struct {
+	GeneratedAt     time.Time       `json:"generatedAt"`
+	Folder          string          `json:"folder"`
+	Template        string          `json:"template"`
+	Output          string          `json:"output"`
+	WorkbookPath    string          `json:"workbookPath,omitempty"`
+	TimestampSource TimestampSource `json:"timestampSource"`
+	IssueDate       *time.Time      `json:"issueDate,omitempty"`
+	Files           []CheckedFile   `json:"files"`
+	Workbook        *CheckedFile    `json:"workbook,omitempty"`
+	TemplateFile    *CheckedFile    `json:"templateFile,omitempty"`
+}
+
+func NewManifest(project *Project, template CheckedFile, options RenderOptions) *Manifest {
+	manifest := &Manifest{
+		GeneratedAt:     time.Now(),
+		Folder:          project.Folder,
+		Template:        options.TemplatePath,
+		Output:          options.OutputPath,
+		WorkbookPath:    project.WorkbookPath,
+		TimestampSource: options.Timestamps.Source,
+		Files:           project.Files,
+	}
+	if options.Timestamps.Source == TimestampIssueDate && !options.Timestamps.IssueDate.IsZero() {
+		manifest.IssueDate = &options.Timestamps.IssueDate
+	}
+	if project.Workbook.FileName != "" {
+		workbook := project.Workbook
+		manifest.Workbook = &workbook
+	}
+	if template.FileName != "" {
+		manifest.TemplateFile = &template
+	}
+	return manifest
+}
+
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	manifest := new(Manifest)
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// ManifestPath returns the manifest location for an output document.
+func ManifestPath(outputFile string) string {
+	return strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + ManifestSuffix
+}
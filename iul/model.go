@@ -0,0 +1,283 @@
+package iul
+
+import (
+	"github.com/xuri/excelize/v2"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// DefaultAuthorTitles are the titles assigned to the authors read from the
+// workbook when no localised titles are supplied.
+var DefaultAuthorTitles = []string{"Разраб.", "Проверил"}
+
+type Author struct {
+	Name  string
+	Title string
+}
+
+// ControlSheet holds the cell values of the "Лист управления" sheet as read
+// from the workbook, row by row.
+type ControlSheet struct {
+	Rows [][]string
+	// Merged are the merged regions; their value is in the top left cell.
+	Merged []MergedCell
+	// Dates are the values of the cells with a date number format, by cell
+	// name. They are formatted with the format profile when shown.
+	Dates map[string]time.Time
+}
+
+// MergedCell is a merged region of zero-based rows and columns, inclusive.
+type MergedCell struct {
+	StartRow int
+	StartCol int
+	EndRow   int
+	EndCol   int
+}
+
+func (m MergedCell) Contains(row, col int) bool {
+	return row >= m.StartRow && row <= m.EndRow && col >= m.StartCol && col <= m.EndCol
+}
+
+// ColumnName returns the Excel name (A, B, ..., AA) of a zero-based column.
+func ColumnName(col int) string {
+	name, err := excelize.ColumnNumberToName(col + 1)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// CellName returns the Excel name (A1, F7...) of a zero-based cell.
+func CellName(row, col int) string {
+	return ColumnName(col) + strconv.Itoa(row+1)
+}
+
+func (c ControlSheet) RowCount() int {
+	return len(c.Rows)
+}
+
+func (c ControlSheet) ColumnCount() int {
+	colsCount := 0
+	for _, row := range c.Rows {
+		colsCount = max(colsCount, len(row))
+	}
+	return colsCount
+}
+
+func (c ControlSheet) Value(row, col int) string {
+	if row < 0 || row >= len(c.Rows) || col < 0 || col >= len(c.Rows[row]) {
+		return ""
+	}
+	return c.Rows[row][col]
+}
+
+// MergedAt returns the merged region containing the cell.
+func (c ControlSheet) MergedAt(row, col int) (MergedCell, bool) {
+	for _, merged := range c.Merged {
+		if merged.Contains(row, col) {
+			return merged, true
+		}
+	}
+	return MergedCell{}, false
+}
+
+func (c *ControlSheet) setDate(name string, date time.Time) {
+	if c.Dates == nil {
+		c.Dates = make(map[string]time.Time)
+	}
+	c.Dates[name] = date
+}
+
+// Cells maps Excel cell names (A1, F7...) to their values.
+func (c ControlSheet) Cells() map[string]string {
+	cells := make(map[string]string)
+	for rowNum, controlRow := range c.Rows {
+		for colNum, value := range controlRow {
+			name, err := excelize.CoordinatesToCellName(colNum+1, rowNum+1)
+			if err != nil {
+				log.Printf("Failed to name cell %d:%d due to %s", rowNum, colNum, err)
+				continue
+			}
+			cells[name] = value
+		}
+	}
+	return cells
+}
+
+// FileStatus tells how a file differs from the project baseline or from a
+// previous revision.
+type FileStatus string
+
+const (
+	StatusUnchanged FileStatus = "unchanged"
+	StatusNew       FileStatus = "new"
+	StatusChanged   FileStatus = "changed"
+	StatusRemoved   FileStatus = "removed"
+)
+
+// DefaultStatusNames are the {{Items_Status}} values used when no localised
+// names are supplied.
+var DefaultStatusNames = map[FileStatus]string{
+	StatusUnchanged: "Без изменений",
+	StatusNew:       "Новый",
+	StatusChanged:   "Изменён",
+	StatusRemoved:   "Удалён",
+}
+
+func fileStatus(previous map[string]CheckedFile, file CheckedFile) FileStatus {
+	previousFile, ok := previous[file.FileName]
+	if !ok {
+		return StatusNew
+	}
+	if previousFile.Checksum != file.Checksum || previousFile.FileSize != file.FileSize {
+		return StatusChanged
+	}
+	return StatusUnchanged
+}
+
+// Project is everything the ИУЛ is rendered from: the scanned folder, the
+// control workbook and the authors list.
+type Project struct {
+	Folder       string
+	Files        []CheckedFile
+	WorkbookPath string
+	Workbook     CheckedFile
+	Control      ControlSheet
+	Authors      []Author
+	AuthorTitles []string
+	// Previous is the manifest of the issue the files are compared with.
+	Previous *Manifest
+
+	baseline map[string]CheckedFile
+}
+
+// Changes compares the files with the previous issue, nil without one.
+func (p *Project) Changes() []FileChange {
+	if p.Previous == nil {
+		return nil
+	}
+	return CompareFiles(p.Previous.Files, p.Files)
+}
+
+// SetWorkbookData replaces the control sheet and authors, assigning the first
+// half of the authors the first default title and the rest the second one.
+func (p *Project) SetWorkbookData(control ControlSheet, authors []Author, defaultTitles []string) {
+	p.Control = control
+	p.Authors = authors
+	p.AuthorTitles = make([]string, 0)
+	seen := make(map[string]bool)
+	for _, author := range authors {
+		if !seen[author.Title] {
+			seen[author.Title] = true
+			p.AuthorTitles = append(p.AuthorTitles, author.Title)
+		}
+	}
+	p.AuthorTitles = append(p.AuthorTitles, defaultTitles...)
+	if len(defaultTitles) < 2 {
+		return
+	}
+	for i := range p.Authors {
+		if i < len(p.Authors)/2 {
+			p.Authors[i].Title = defaultTitles[0]
+		} else {
+			p.Authors[i].Title = defaultTitles[1]
+		}
+	}
+}
+
+// SetBaseline remembers the current files, usually after a full scan or a
+// render, so that later changes can be reported by FileStatus.
+func (p *Project) SetBaseline() {
+	p.baseline = make(map[string]CheckedFile, len(p.Files))
+	for _, file := range p.Files {
+		p.baseline[file.FileName] = file
+	}
+}
+
+func (p *Project) FileStatus(file CheckedFile) FileStatus {
+	if p.baseline == nil {
+		return StatusUnchanged
+	}
+	return fileStatus(p.baseline, file)
+}
+
+// UpdateFiles applies the result of Rescan: changed files replace the rows with
+// the same name in place, unknown ones are appended and removed ones dropped.
+// The entries of a changed archive replace its previous entries right after
+// the archive row.
+func (p *Project) UpdateFiles(changed []CheckedFile, removed []string) {
+	entries := make(map[string][]CheckedFile)
+	for _, file := range changed {
+		if file.Archive != "" {
+			entries[file.Archive] = append(entries[file.Archive], file)
+		}
+	}
+	for _, file := range changed {
+		if file.Archive != "" {
+			continue
+		}
+		p.Files = slices.DeleteFunc(p.Files, func(existing CheckedFile) bool {
+			return existing.Archive == file.FileName
+		})
+		index := slices.IndexFunc(p.Files, func(existing CheckedFile) bool {
+			return existing.FileName == file.FileName
+		})
+		if index < 0 {
+			p.Files = append(p.Files, file)
+			index = len(p.Files) - 1
+		} else {
+			p.Files[index] = file
+		}
+		p.Files = slices.Insert(p.Files, index+1, entries[file.FileName]...)
+	}
+	p.Files = slices.DeleteFunc(p.Files, func(file CheckedFile) bool {
+		return slices.Contains(removed, file.FileName) || slices.Contains(removed, file.Archive)
+	})
+}
+
+func (p *Project) MoveFile(src, dst int) {
+	p.Files = moveItem(p.Files, src, dst)
+}
+
+func (p *Project) MoveAuthor(src, dst int) {
+	p.Authors = moveItem(p.Authors, src, dst)
+}
+
+func (p *Project) RemoveAuthor(index int) {
+	if index < 0 || index >= len(p.Authors) {
+		return
+	}
+	p.Authors = append(p.Authors[:index], p.Authors[index+1:]...)
+}
+
+// moveItem moves the element at src to dst. Moving the first element up or the
+// last element down wraps it around to the other end of the slice.
+func moveItem[T any](slice []T, src, dst int) []T {
+	sliceLen := len(slice)
+	if src < 0 || src >= sliceLen {
+		return slice
+	}
+	value := slice[src]
+	if src == 0 && dst == -1 {
+		slice = slice[src+1:]
+		slice = append(slice, value)
+		return slice
+	}
+	if src == sliceLen-1 && dst == sliceLen {
+		slice = slice[:src]
+		slice = append([]T{value}, slice...)
+		return slice
+	}
+	if dst < 0 || dst >= sliceLen {
+		return slice
+	}
+	var zero T
+	copy(slice[src:], slice[src+1:])
+	slice = slice[:len(slice)-1]
+	slice = append(slice, zero)
+	copy(slice[dst+1:], slice[dst:])
+	slice[dst] = value
+	return slice
+}
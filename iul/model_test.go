@@ -0,0 +1,103 @@
+package iul
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMoveItem(t *testing.T) {
+	tests := []struct {
+		name     string
+		src, dst int
+		want     []string
+	}{
+		{name: "middle up", src: 2, dst: 1, want: []string{"a", "c", "b", "d"}},
+		{name: "middle down", src: 1, dst: 2, want: []string{"a", "c", "b", "d"}},
+		{name: "first up wraps to end", src: 0, dst: -1, want: []string{"b", "c", "d", "a"}},
+		{name: "last down wraps to start", src: 3, dst: 4, want: []string{"d", "a", "b", "c"}},
+		{name: "first down", src: 0, dst: 1, want: []string{"b", "a", "c", "d"}},
+		{name: "last up", src: 3, dst: 2, want: []string{"a", "b", "d", "c"}},
+		{name: "source out of range", src: 5, dst: 4, want: []string{"a", "b", "c", "d"}},
+		{name: "negative source", src: -1, dst: 0, want: []string{"a", "b", "c", "d"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := moveItem([]string{"a", "b", "c", "d"}, test.src, test.dst)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("moveItem(%d, %d) = %v, want %v", test.src, test.dst, got, test.want)
+			}
+		})
+	}
+}
+
+func TestProjectMoveFileAndAuthor(t *testing.T) {
+	project := &Project{
+		Files:   []CheckedFile{{FileName: "a.pdf"}, {FileName: "b.pdf"}, {FileName: "c.pdf"}},
+		Authors: []Author{{Name: "Иванов"}, {Name: "Петров"}},
+	}
+	project.MoveFile(2, 1)
+	if got := project.Files[1].FileName; got != "c.pdf" {
+		t.Errorf("Files[1] = %q after MoveFile(2, 1), want c.pdf", got)
+	}
+	if len(project.Files) != 3 {
+		t.Errorf("len(Files) = %d, want 3", len(project.Files))
+	}
+	project.MoveAuthor(1, 2)
+	if got := project.Authors[0].Name; got != "Петров" {
+		t.Errorf("Authors[0] = %q after wrapping MoveAuthor, want Петров", got)
+	}
+	project.RemoveAuthor(0)
+	project.RemoveAuthor(5)
+	if len(project.Authors) != 1 || project.Authors[0].Name != "Иванов" {
+		t.Errorf("Authors = %v after RemoveAuthor, want [Иванов]", project.Authors)
+	}
+}
+
+func TestSetWorkbookDataAssignsDefaultTitles(t *testing.T) {
+	project := new(Project)
+	authors := []Author{{Title: "Роль", Name: "А"}, {Title: "Роль", Name: "Б"}, {Title: "ГИП", Name: "В"}}
+	project.SetWorkbookData(ControlSheet{}, authors, DefaultAuthorTitles)
+
+	wantTitles := []string{"Роль", "ГИП", "Разраб.", "Проверил"}
+	if !reflect.DeepEqual(project.AuthorTitles, wantTitles) {
+		t.Errorf("AuthorTitles = %v, want %v", project.AuthorTitles, wantTitles)
+	}
+	for i, want := range []string{"Разраб.", "Проверил", "Проверил"} {
+		if project.Authors[i].Title != want {
+			t.Errorf("Authors[%d].Title = %q, want %q", i, project.Authors[i].Title, want)
+		}
+	}
+}
+
+func TestControlSheetCells(t *testing.T) {
+	control := ControlSheet{Rows: [][]string{{"a", "b"}, {}, {"", "", "c"}}}
+	if got := control.ColumnCount(); got != 3 {
+		t.Errorf("ColumnCount() = %d, want 3", got)
+	}
+	if got := control.Value(2, 2); got != "c" {
+		t.Errorf("Value(2, 2) = %q, want c", got)
+	}
+	if got := control.Value(1, 5); got != "" {
+		t.Errorf("Value(1, 5) = %q, want empty", got)
+	}
+	cells := control.Cells()
+	if cells["A1"] != "a" || cells["B1"] != "b" || cells["C3"] != "c" {
+		t.Errorf("Cells() = %v", cells)
+	}
+}
+
+func TestControlSheetMerged(t *testing.T) {
+	control := ControlSheet{Merged: []MergedCell{{StartRow: 0, StartCol: 1, EndRow: 1, EndCol: 3}}}
+	if merged, ok := control.MergedAt(1, 2); !ok || merged.StartCol != 1 {
+		t.Errorf("MergedAt(1, 2) = %+v, %v", merged, ok)
+	}
+	if _, ok := control.MergedAt(2, 2); ok {
+		t.Error("MergedAt(2, 2) found a region below the merged cells")
+	}
+	if got := CellName(6, 5); got != "F7" {
+		t.Errorf("CellName(6, 5) = %q, want F7", got)
+	}
+	if got := ColumnName(26); got != "AA" {
+		t.Errorf("ColumnName(26) = %q, want AA", got)
+	}
+}
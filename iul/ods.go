@@ -0,0 +1,254 @@
+package iul
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	odsOfficeNamespace = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odsTableNamespace  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsTextNamespace   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odsContentFile     = "content.xml"
+)
+
+// odsWorkbook reads OpenDocument spreadsheets. The cells keep the values and
+// the displayed text they were saved with.
+type odsWorkbook struct {
+	zip *zip.ReadCloser
+}
+
+func openODS(path string) (*odsWorkbook, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	return &odsWorkbook{zip: reader}, nil
+}
+
+func (w *odsWorkbook) Close() error {
+	return w.zip.Close()
+}
+
+func (w *odsWorkbook) Sheet(name string, options WorkbookOptions) (ControlSheet, error) {
+	content, err := w.zip.Open(odsContentFile)
+	if err != nil {
+		return ControlSheet{}, err
+	}
+	defer content.Close()
+	decoder := xml.NewDecoder(content)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return ControlSheet{}, fmt.Errorf("sheet %s does not exist", name)
+		}
+		if err != nil {
+			return ControlSheet{}, err
+		}
+		start, ok := token.(xml.StartElement)
+		if ok && isODSElement(start.Name, odsTableNamespace, "table") && odsAttr(start, odsTableNamespace, "name") == name {
+			return readODSTable(decoder, options)
+		}
+	}
+}
+
+func isODSElement(name xml.Name, space string, local string) bool {
+	return name.Space == space && name.Local == local
+}
+
+func odsAttr(start xml.StartElement, space string, local string) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == space && attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// odsCount reads a repeat or span count, 1 when it is missing.
+func odsCount(start xml.StartElement, local string) int {
+	count, err := strconv.Atoi(odsAttr(start, odsTableNamespace, local))
+	if err != nil || count < 1 {
+		return 1
+	}
+	return count
+}
+
+// readODSTable reads the rows of the table the decoder is in. Empty rows and
+// cells, which are repeated up to the sheet size, are skipped over.
+func readODSTable(decoder *xml.Decoder, options WorkbookOptions) (ControlSheet, error) {
+	var control ControlSheet
+	row := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return control, err
+		}
+		switch token := token.(type) {
+		case xml.EndElement:
+			if isODSElement(token.Name, odsTableNamespace, "table") {
+				return control, nil
+			}
+		case xml.StartElement:
+			if !isODSElement(token.Name, odsTableNamespace, "table-row") {
+				continue
+			}
+			cells, err := readODSRow(decoder)
+			if err != nil {
+				return control, err
+			}
+			repeat := odsCount(token, "number-rows-repeated")
+			if len(cells) == 0 {
+				row += repeat
+				continue
+			}
+			for range repeat {
+				for _, cell := range cells {
+					cell.add(&control, row, options)
+				}
+				row++
+			}
+		}
+	}
+}
+
+type odsCell struct {
+	col       int
+	valueType string
+	value     string
+	text      string
+	colSpan   int
+	rowSpan   int
+}
+
+func (c odsCell) add(control *ControlSheet, row int, options WorkbookOptions) {
+	if c.colSpan > 1 || c.rowSpan > 1 {
+		control.Merged = append(control.Merged, MergedCell{StartRow: row, StartCol: c.col, EndRow: row + c.rowSpan - 1, EndCol: c.col + c.colSpan - 1})
+	}
+	if c.valueType == "date" {
+		if date, ok := parseDate(c.value); ok {
+			control.setDate(CellName(row, c.col), date)
+		}
+	}
+	value := c.text
+	if options.RawValues && c.value != "" {
+		value = c.value
+	}
+	if value != "" {
+		control.Rows = setCellValue(control.Rows, row, c.col, value)
+	}
+}
+
+// readODSRow reads the cells of the row the decoder is in, leaving out the
+// empty cells that are not merged.
+func readODSRow(decoder *xml.Decoder) ([]odsCell, error) {
+	var cells []odsCell
+	col := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.EndElement:
+			if isODSElement(token.Name, odsTableNamespace, "table-row") {
+				return cells, nil
+			}
+		case xml.StartElement:
+			covered := isODSElement(token.Name, odsTableNamespace, "covered-table-cell")
+			if !covered && !isODSElement(token.Name, odsTableNamespace, "table-cell") {
+				continue
+			}
+			text, err := readODSText(decoder, token.Name)
+			if err != nil {
+				return nil, err
+			}
+			cell := odsCell{
+				valueType: odsAttr(token, odsOfficeNamespace, "value-type"),
+				value:     odsValue(token),
+				text:      text,
+				colSpan:   odsCount(token, "number-columns-spanned"),
+				rowSpan:   odsCount(token, "number-rows-spanned"),
+			}
+			repeat := odsCount(token, "number-columns-repeated")
+			if covered || cell.text == "" && cell.value == "" && cell.colSpan == 1 && cell.rowSpan == 1 {
+				col += repeat
+				continue
+			}
+			for range repeat {
+				cell.col = col
+				cells = append(cells, cell)
+				col++
+			}
+		}
+	}
+}
+
+// odsValue returns the stored value of a cell by its value type.
+func odsValue(start xml.StartElement) string {
+	switch odsAttr(start, odsOfficeNamespace, "value-type") {
+	case "float", "percentage", "currency":
+		return odsAttr(start, odsOfficeNamespace, "value")
+	case "date":
+		return odsAttr(start, odsOfficeNamespace, "date-value")
+	case "time":
+		return odsAttr(start, odsOfficeNamespace, "time-value")
+	case "boolean":
+		return strings.ToUpper(odsAttr(start, odsOfficeNamespace, "boolean-value"))
+	}
+	return odsAttr(start, odsOfficeNamespace, "string-value")
+}
+
+// readODSText returns the displayed text of the cell the decoder is in: its
+// paragraphs joined by line breaks. Comments are skipped.
+func readODSText(decoder *xml.Decoder, cell xml.Name) (string, error) {
+	var text strings.Builder
+	paragraphs := 0
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch {
+			case isODSElement(token.Name, odsOfficeNamespace, "annotation"):
+				if err := decoder.Skip(); err != nil {
+					return "", err
+				}
+				continue
+			case isODSElement(token.Name, odsTextNamespace, "p"):
+				if paragraphs > 0 {
+					text.WriteString("\n")
+				}
+				paragraphs++
+			case isODSElement(token.Name, odsTextNamespace, "s"):
+				count, err := strconv.Atoi(odsAttr(token, odsTextNamespace, "c"))
+				if err != nil || count < 1 {
+					count = 1
+				}
+				text.WriteString(strings.Repeat(" ", count))
+			case isODSElement(token.Name, odsTextNamespace, "tab"):
+				text.WriteString("\t")
+			case isODSElement(token.Name, odsTextNamespace, "line-break"):
+				text.WriteString("\n")
+			}
+			depth++
+		case xml.EndElement:
+			if depth == 0 && token.Name == cell {
+				return text.String(), nil
+			}
+			depth--
+		case xml.CharData:
+			if depth > 0 {
+				text.Write(token)
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package iul
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// OutputVersionField is replaced with the first version number that gives
+	// a file name not taken yet.
+	OutputVersionField  = "{n}"
+	outputControlPrefix = "Control."
+	outputVersionFormat = "_v%d"
+	backupSuffix        = ".bak"
+	backupTimeLayout    = "20060102-150405"
+)
+
+var (
+	rxOutputField    = regexp.MustCompile(`\{([^{}]+)\}`)
+	rxFileNameUnsafe = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+	rxBackupName     = regexp.MustCompile(`_\d{8}-\d{6}\` + backupSuffix + `\.[^.]+$`)
+)
+
+func fileExists(filePath string) bool {
+	_, err := os.Stat(filePath)
+	return err == nil
+}
+
+// expandOutputPattern fills the {Control.XX} fields of the file name with the
+// control sheet cells, made safe for file names, and {n} with version.
+// Unknown fields are kept as they are.
+func expandOutputPattern(pattern string, control map[string]string, version int) string {
+	dir, name := filepath.Split(pattern)
+	name = rxOutputField.ReplaceAllStringFunc(name, func(field string) string {
+		if field == OutputVersionField {
+			return strconv.Itoa(version)
+		}
+		cell, ok := strings.CutPrefix(field[1:len(field)-1], outputControlPrefix)
+		if !ok {
+			return field
+		}
+		value := rxFileNameUnsafe.ReplaceAllString(control[cell], "_")
+		return strings.Trim(value, " .")
+	})
+	return dir + name
+}
+
+// ResolveOutputPath returns the output document for pattern. A pattern with
+// {n} gets the first free version number. Otherwise, when autoVersion is set
+// and the file exists, a _v2, _v3, ... suffix is added before the extension.
+func ResolveOutputPath(pattern string, control map[string]string, autoVersion bool) string {
+	if strings.Contains(pattern, OutputVersionField) {
+		version := 1
+		for fileExists(expandOutputPattern(pattern, control, version)) {
+			version++
+		}
+		return expandOutputPattern(pattern, control, version)
+	}
+	outputPath := expandOutputPattern(pattern, control, 0)
+	if !autoVersion || !fileExists(outputPath) {
+		return outputPath
+	}
+	extension := filepath.Ext(outputPath)
+	base := strings.TrimSuffix(outputPath, extension)
+	version := 2
+	for fileExists(base + fmt.Sprintf(outputVersionFormat, version) + extension) {
+		version++
+	}
+	return base + fmt.Sprintf(outputVersionFormat, version) + extension
+}
+
+// OutputMatcher tells whether a file is one of the documents an output
+// pattern resolves to. The zero OutputMatcher matches nothing.
+type OutputMatcher struct {
+	dir string
+	rx  *regexp.Regexp
+}
+
+// NewOutputMatcher matches the documents of pattern with the control cells:
+// every {n} version and every _v2, _v3, ... suffix, and their packages.
+func NewOutputMatcher(pattern string, control map[string]string) OutputMatcher {
+	if pattern == "" {
+		return OutputMatcher{}
+	}
+	dir, err := filepath.Abs(filepath.Dir(pattern))
+	if err != nil {
+		return OutputMatcher{}
+	}
+	name := filepath.Base(pattern)
+	extension := filepath.Ext(name)
+	parts := strings.Split(strings.TrimSuffix(name, extension), OutputVersionField)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(expandOutputPattern(part, control, 0))
+	}
+	rx, err := regexp.Compile(`^` + strings.Join(parts, `\d+`) + `(_v\d+)?(` + regexp.QuoteMeta(extension) + `|` + regexp.QuoteMeta(PackageExtension) + `)$`)
+	if err != nil {
+		return OutputMatcher{}
+	}
+	return OutputMatcher{dir: dir, rx: rx}
+}
+
+func (m OutputMatcher) Match(filePath string) bool {
+	if m.rx == nil {
+		return false
+	}
+	dir, err := filepath.Abs(filepath.Dir(filePath))
+	return err == nil && dir == m.dir && m.rx.MatchString(filepath.Base(filePath))
+}
+
+// isBackup tells whether the file name is one BackupPath gives.
+func isBackup(name string) bool {
+	return rxBackupName.MatchString(name)
+}
+
+// BackupPath returns the name the output document is moved to before it is
+// overwritten, stamped with the given time.
+func BackupPath(outputPath string, now time.Time) string {
+	extension := filepath.Ext(outputPath)
+	return strings.TrimSuffix(outputPath, extension) + "_" + now.Format(backupTimeLayout) + backupSuffix + extension
+}
+
+// BackupOutput moves an existing output document, its manifest and their
+// signatures to the backup name. It returns an empty path when there is no
+// previous output.
+func BackupOutput(outputPath string) (string, error) {
+	if !fileExists(outputPath) {
+		return "", nil
+	}
+	backupPath := BackupPath(outputPath, time.Now())
+	manifestPath := ManifestPath(outputPath)
+	backupManifestPath := ManifestPath(backupPath)
+	moves := [][2]string{
+		{outputPath, backupPath},
+		{SignaturePath(outputPath), SignaturePath(backupPath)},
+		{manifestPath, backupManifestPath},
+		{SignaturePath(manifestPath), SignaturePath(backupManifestPath)},
+	}
+	for _, move := range moves {
+		err := os.Rename(move[0], move[1])
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	return backupPath, nil
+}
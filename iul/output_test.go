@@ -0,0 +1,116 @@
+package iul
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResolveOutputPath(t *testing.T) {
+	dir := t.TempDir()
+	control := map[string]string{"F7": "123/2024 "}
+	pattern := filepath.Join(dir, "{Control.F7}_ИУЛ_v{n}.docx")
+
+	if got, want := ResolveOutputPath(pattern, control, false), filepath.Join(dir, "123_2024_ИУЛ_v1.docx"); got != want {
+		t.Errorf("ResolveOutputPath() = %s, want %s", got, want)
+	}
+	writeFile(t, dir, "123_2024_ИУЛ_v1.docx", "v1")
+	writeFile(t, dir, "123_2024_ИУЛ_v2.docx", "v2")
+	if got, want := ResolveOutputPath(pattern, control, false), filepath.Join(dir, "123_2024_ИУЛ_v3.docx"); got != want {
+		t.Errorf("ResolveOutputPath() = %s, want %s", got, want)
+	}
+
+	plain := filepath.Join(dir, "result.docx")
+	writeFile(t, dir, "result.docx", "issued")
+	if got := ResolveOutputPath(plain, control, false); got != plain {
+		t.Errorf("ResolveOutputPath() without auto version = %s, want %s", got, plain)
+	}
+	if got, want := ResolveOutputPath(plain, control, true), filepath.Join(dir, "result_v2.docx"); got != want {
+		t.Errorf("ResolveOutputPath() with auto version = %s, want %s", got, want)
+	}
+	if got, want := ResolveOutputPath(filepath.Join(dir, "{Other}_{Control.A1}.docx"), control, false), filepath.Join(dir, "{Other}_.docx"); got != want {
+		t.Errorf("ResolveOutputPath() = %s, want %s", got, want)
+	}
+}
+
+func TestBackupOutput(t *testing.T) {
+	dir := t.TempDir()
+	outputPath := writeFile(t, dir, "result.docx", "issued")
+	manifestPath := writeFile(t, dir, filepath.Base(ManifestPath(outputPath)), "{}")
+	writeFile(t, dir, filepath.Base(SignaturePath(manifestPath)), "signature")
+
+	backupPath, err := BackupOutput(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(backupPath) != dir || filepath.Ext(backupPath) != ".docx" || backupPath == outputPath {
+		t.Fatalf("BackupOutput() = %s", backupPath)
+	}
+	for _, moved := range []string{outputPath, manifestPath, SignaturePath(manifestPath)} {
+		if _, err := os.Stat(moved); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after backup", moved)
+		}
+	}
+	for _, kept := range []string{backupPath, ManifestPath(backupPath), SignaturePath(ManifestPath(backupPath))} {
+		if _, err := os.Stat(kept); err != nil {
+			t.Error(err)
+		}
+	}
+	if content, _ := os.ReadFile(backupPath); string(content) != "issued" {
+		t.Errorf("backup content = %q, want %q", content, "issued")
+	}
+
+	if backupPath, err := BackupOutput(outputPath); err != nil || backupPath != "" {
+		t.Errorf("BackupOutput() without output = %q, %v", backupPath, err)
+	}
+}
+
+func TestBackupPath(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 30, 0, 0, time.Local)
+	if got, want := BackupPath("/out/result.docx", now), "/out/result_20240301-103000.bak.docx"; got != want {
+		t.Errorf("BackupPath() = %s, want %s", got, want)
+	}
+}
+
+func TestScanExcludesOutputs(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, dir, "a.pdf", "a")
+	writeFile(t, dir, "other.docx", "other")
+	control := map[string]string{"F7": "123"}
+	pattern := filepath.Join(dir, "{Control.F7}_ИУЛ_v{n}.docx")
+	render := func(outputPath string, keepBackup bool) {
+		t.Helper()
+		files, err := Scan(context.Background(), dir, ScanOptions{Output: NewOutputMatcher(pattern, control)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		options := RenderOptions{OutputPath: outputPath, KeepBackup: keepBackup, Profile: FindFormatProfile(DefaultFormatProfile)}
+		if err := Render(context.Background(), &Project{Folder: dir, Files: files}, options); err != nil {
+			t.Fatal(err)
+		}
+	}
+	render(ResolveOutputPath(pattern, control, false), false)
+	second := ResolveOutputPath(pattern, control, false)
+	render(second, false)
+	render(second, true)
+	render(filepath.Join(dir, "123_ИУЛ_v2_v2.docx"), false)
+
+	// A scan after a restart knows only the pattern, not the last output.
+	files, err := Scan(context.Background(), dir, ScanOptions{Output: NewOutputMatcher(pattern, control)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fileNames(files), []string{"a.pdf", "other.docx"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan() = %v, want %v", got, want)
+	}
+	if NewOutputMatcher(pattern, map[string]string{"F7": "456"}).Match(filepath.Join(dir, "123_ИУЛ_v1.docx")) {
+		t.Error("Match() matched the output of other control cells")
+	}
+	if (OutputMatcher{}).Match(filepath.Join(dir, "other.docx")) {
+		t.Error("zero OutputMatcher matched a file")
+	}
+}
@@ -0,0 +1,251 @@
+package iul
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const PackageExtension = ".zip"
+
+var pdfConverters = []string{"soffice", "libreoffice"}
+
+var ErrNoPDFConverter = errors.New("LibreOffice (soffice) is not installed")
+
+// PackageOptions controls what ExportPackage adds next to the listed files.
+type PackageOptions struct {
+	// PDF adds a PDF copy of the rendered document made with LibreOffice.
+	PDF bool
+}
+
+// PackageMismatchError reports the entries of a written package that do not
+// match the manifest.
+type PackageMismatchError struct {
+	Mismatches []Mismatch
+}
+
+func (e *PackageMismatchError) Error() string {
+	names := make([]string, len(e.Mismatches))
+	for i, mismatch := range e.Mismatches {
+		names[i] = mismatch.FileName
+	}
+	return "package does not match the manifest: " + strings.Join(names, ", ")
+}
+
+// PackagePath returns the default package location for an output document.
+func PackagePath(outputFile string) string {
+	return strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + PackageExtension
+}
+
+func pdfConverter() (string, bool) {
+	for _, name := range pdfConverters {
+		if converterPath, err := exec.LookPath(name); err == nil {
+			return converterPath, true
+		}
+	}
+	return "", false
+}
+
+// PDFConverterAvailable tells whether ConvertToPDF can be used.
+func PDFConverterAvailable() bool {
+	_, ok := pdfConverter()
+	return ok
+}
+
+// ConvertToPDF converts the document with LibreOffice into outputDir and
+// returns the path of the PDF.
+func ConvertToPDF(ctx context.Context, documentPath string, outputDir string) (string, error) {
+	converter, ok := pdfConverter()
+	if !ok {
+		return "", ErrNoPDFConverter
+	}
+	command := exec.CommandContext(ctx, converter, "--headless", "--convert-to", "pdf", "--outdir", outputDir, documentPath)
+	if output, err := command.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	pdfPath := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(documentPath), filepath.Ext(documentPath))+".pdf")
+	if _, err := os.Stat(pdfPath); err != nil {
+		return "", err
+	}
+	return pdfPath, nil
+}
+
+// ExportPackage writes a ZIP with the files recorded in the manifest, the
+// control workbook, the template when it was listed, the rendered document
+// and the manifest itself together with their detached signatures when
+// present, then reads the package back and checks every listed file, the
+// workbook and the template against the manifest. The package is only left
+// at packagePath when the check passes.
+func ExportPackage(ctx context.Context, manifestPath string, packagePath string, options PackageOptions) error {
+	manifest, err := LoadManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	sources := make(map[string]string)
+	var names []string
+	addSource := func(name string, sourcePath string) error {
+		if _, ok := sources[name]; ok {
+			return fmt.Errorf("%s is added to the package twice", name)
+		}
+		sources[name] = sourcePath
+		names = append(names, name)
+		if _, err := os.Stat(SignaturePath(sourcePath)); err == nil {
+			sources[SignaturePath(name)] = SignaturePath(sourcePath)
+			names = append(names, SignaturePath(name))
+		}
+		return nil
+	}
+	var listed []CheckedFile
+	addListed := func(file CheckedFile, sourcePath string) error {
+		if sources[file.FileName] == sourcePath {
+			return nil
+		}
+		listed = append(listed, file)
+		return addSource(file.FileName, sourcePath)
+	}
+	for _, file := range manifest.Files {
+		if file.Archive != "" {
+			continue
+		}
+		if err := addListed(file, filepath.Join(manifest.Folder, file.FileName)); err != nil {
+			return err
+		}
+	}
+	if manifest.Workbook != nil && manifest.WorkbookPath != "" {
+		workbook := *manifest.Workbook
+		workbook.FileName = filepath.Base(manifest.WorkbookPath)
+		if err := addListed(workbook, manifest.WorkbookPath); err != nil {
+			return err
+		}
+	}
+	if manifest.TemplateFile != nil {
+		templatePath, err := resolveTemplatePath(manifest.Template)
+		if err != nil {
+			return err
+		}
+		template := *manifest.TemplateFile
+		template.FileName = filepath.Base(templatePath)
+		if manifest.Template == "" {
+			template.FileName = BuiltinTemplateName
+		}
+		if err := addListed(template, templatePath); err != nil {
+			return err
+		}
+	}
+	if err := addSource(filepath.Base(manifest.Output), manifest.Output); err != nil {
+		return err
+	}
+	if err := addSource(filepath.Base(manifestPath), manifestPath); err != nil {
+		return err
+	}
+	if options.PDF {
+		temporaryDir, err := os.MkdirTemp("", "iul-pdf-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(temporaryDir)
+		pdfPath, err := ConvertToPDF(ctx, manifest.Output, temporaryDir)
+		if err != nil {
+			return err
+		}
+		if err := addSource(filepath.Base(pdfPath), pdfPath); err != nil {
+			return err
+		}
+	}
+
+	temporaryPath := packagePath + ".tmp"
+	if err := writePackage(ctx, temporaryPath, names, sources); err != nil {
+		_ = os.Remove(temporaryPath)
+		return err
+	}
+	mismatches, err := VerifyPackage(ctx, temporaryPath, listed)
+	if err == nil && len(mismatches) > 0 {
+		err = &PackageMismatchError{Mismatches: mismatches}
+	}
+	if err != nil {
+		_ = os.Remove(temporaryPath)
+		return err
+	}
+	return os.Rename(temporaryPath, packagePath)
+}
+
+func writePackage(ctx context.Context, packagePath string, names []string, sources map[string]string) (err error) {
+	packageFile, err := os.Create(packagePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := packageFile.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	writer := zip.NewWriter(packageFile)
+	for _, name := range names {
+		if err := addPackageEntry(ctx, writer, name, sources[name]); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func addPackageEntry(ctx context.Context, writer *zip.Writer, name string, sourcePath string) error {
+	source, err := os.Open(sourcePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	entry, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, contextReader{ctx: ctx, reader: source})
+	return err
+}
+
+// VerifyPackage reads every entry of the package, which also checks the
+// stored CRC, and reports the expected files that are missing or differ.
+func VerifyPackage(ctx context.Context, packagePath string, files []CheckedFile) ([]Mismatch, error) {
+	reader, err := zip.OpenReader(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	entries := make(map[string]*zip.File, len(reader.File))
+	for _, zipFile := range reader.File {
+		entries[zipFile.Name] = zipFile
+	}
+	var mismatches []Mismatch
+	for _, expected := range files {
+		zipFile, ok := entries[expected.FileName]
+		if !ok {
+			mismatches = append(mismatches, Mismatch{FileName: expected.FileName, Expected: expected, Missing: true})
+			continue
+		}
+		checksum, err := zipEntryChecksum(ctx, zipFile)
+		if err != nil {
+			return nil, err
+		}
+		actual := CheckedFile{FileName: expected.FileName, Checksum: formatChecksum(checksum), FileSize: int64(zipFile.UncompressedSize64)}
+		if actual.Checksum != expected.Checksum || actual.FileSize != expected.FileSize {
+			mismatches = append(mismatches, Mismatch{FileName: expected.FileName, Expected: expected, Actual: actual})
+		}
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,169 @@
+package iul
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTestManifest(t *testing.T, dir string) string {
+	t.Helper()
+	folder := filepath.Join(dir, "folder")
+	if err := os.Mkdir(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, folder, "a.pdf", "a")
+	writeFile(t, folder, "b.pdf", "b")
+	output := writeFile(t, dir, "result.docx", "document")
+	files, err := Scan(context.Background(), folder, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := NewManifest(&Project{Folder: folder, Files: files}, CheckedFile{}, RenderOptions{OutputPath: output})
+	manifestPath := ManifestPath(output)
+	if err := manifest.Save(manifestPath); err != nil {
+		t.Fatal(err)
+	}
+	return manifestPath
+}
+
+func TestExportPackage(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := writeTestManifest(t, dir)
+	packagePath := filepath.Join(dir, "package.zip")
+
+	if err := ExportPackage(context.Background(), manifestPath, packagePath, PackageOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.OpenReader(packagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var names []string
+	for _, zipFile := range reader.File {
+		names = append(names, zipFile.Name)
+	}
+	if want := []string{"a.pdf", "b.pdf", "result.docx", "result" + ManifestSuffix}; !reflect.DeepEqual(names, want) {
+		t.Errorf("package entries = %v, want %v", names, want)
+	}
+}
+
+func TestExportPackageIncludesWorkbookAndTemplate(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := writeTestManifest(t, dir)
+	manifest, err := LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.WorkbookPath = writeFile(t, dir, "project.xlsx", "workbook")
+	workbook, err := ChecksumPath(context.Background(), manifest.WorkbookPath, TimestampOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.Workbook = &workbook
+	manifest.Template = writeFile(t, dir, "custom.docx", "template")
+	template, err := ChecksumPath(context.Background(), manifest.Template, TimestampOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.TemplateFile = &template
+	if err := manifest.Save(manifestPath); err != nil {
+		t.Fatal(err)
+	}
+	packagePath := filepath.Join(dir, "package.zip")
+
+	if err := ExportPackage(context.Background(), manifestPath, packagePath, PackageOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.OpenReader(packagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var names []string
+	for _, zipFile := range reader.File {
+		names = append(names, zipFile.Name)
+	}
+	if want := []string{"a.pdf", "b.pdf", "project.xlsx", "custom.docx", "result.docx", "result" + ManifestSuffix}; !reflect.DeepEqual(names, want) {
+		t.Errorf("package entries = %v, want %v", names, want)
+	}
+
+	// The workbook is checked like the listed files.
+	writeFile(t, dir, "project.xlsx", "changed after render")
+	err = ExportPackage(context.Background(), manifestPath, packagePath, PackageOptions{})
+	var mismatchErr *PackageMismatchError
+	if !errors.As(err, &mismatchErr) || len(mismatchErr.Mismatches) != 1 || mismatchErr.Mismatches[0].FileName != "project.xlsx" {
+		t.Errorf("ExportPackage() error = %v, want a mismatch of project.xlsx", err)
+	}
+}
+
+func TestExportPackageRejectsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := writeTestManifest(t, dir)
+	packagePath := filepath.Join(dir, "package.zip")
+	writeFile(t, filepath.Join(dir, "folder"), "b.pdf", "changed after render")
+
+	err := ExportPackage(context.Background(), manifestPath, packagePath, PackageOptions{})
+	var mismatchErr *PackageMismatchError
+	if !errors.As(err, &mismatchErr) || len(mismatchErr.Mismatches) != 1 || mismatchErr.Mismatches[0].FileName != "b.pdf" {
+		t.Fatalf("ExportPackage() error = %v, want a mismatch of b.pdf", err)
+	}
+	if _, err := os.Stat(packagePath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("package was left behind after a failed check: %v", err)
+	}
+	if _, err := os.Stat(packagePath + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary package was left behind: %v", err)
+	}
+}
+
+func TestExportPackagePDF(t *testing.T) {
+	if PDFConverterAvailable() {
+		t.Skip("LibreOffice is installed, the conversion itself is not tested")
+	}
+	dir := t.TempDir()
+	manifestPath := writeTestManifest(t, dir)
+	err := ExportPackage(context.Background(), manifestPath, filepath.Join(dir, "package.zip"), PackageOptions{PDF: true})
+	if !errors.Is(err, ErrNoPDFConverter) {
+		t.Errorf("ExportPackage(PDF) error = %v, want ErrNoPDFConverter", err)
+	}
+}
+
+func TestScanExcludesPackage(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, dir, "a.pdf", "a")
+	pattern := filepath.Join(dir, "result.docx")
+	options := ScanOptions{Output: NewOutputMatcher(pattern, nil), ExpandArchives: true}
+	files, err := Scan(context.Background(), dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renderOptions := RenderOptions{OutputPath: pattern, Profile: FindFormatProfile(DefaultFormatProfile)}
+	if err := Render(context.Background(), &Project{Folder: dir, Files: files}, renderOptions); err != nil {
+		t.Fatal(err)
+	}
+	packagePath := PackagePath(pattern)
+	if err := ExportPackage(context.Background(), ManifestPath(pattern), packagePath, PackageOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err = Scan(context.Background(), dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fileNames(files), []string{"a.pdf"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan() = %v, want %v", got, want)
+	}
+	changed, removed, err := Rescan(context.Background(), dir, []string{filepath.Base(packagePath)}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 0 || len(removed) != 0 {
+		t.Errorf("Rescan(package) = %v, %v, want nothing", changed, removed)
+	}
+}
@@ -0,0 +1,152 @@
+package iul
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	DefaultFirstPageRows        = 0
+	DefaultContinuationPageRows = 0
+)
+
+// Pagination describes how many item rows fit on the first sheet of the form
+// and on every continuation sheet. Zero rows on the first sheet disables
+// pagination and keeps every item on a single sheet.
+type Pagination struct {
+	FirstPageRows        int
+	ContinuationPageRows int
+}
+
+type Sheet struct {
+	Page  int
+	Pages int
+	Items []ItemRow
+}
+
+func paginateItems(items []ItemRow, pagination Pagination) ([]ItemRow, []Sheet) {
+	if pagination.FirstPageRows <= 0 || len(items) <= pagination.FirstPageRows {
+		return items, nil
+	}
+	firstPage := items[:pagination.FirstPageRows]
+	rest := items[pagination.FirstPageRows:]
+	perPage := pagination.ContinuationPageRows
+	if perPage <= 0 {
+		perPage = len(rest)
+	}
+	var sheets []Sheet
+	for start := 0; start < len(rest); start += perPage {
+		end := min(start+perPage, len(rest))
+		sheets = append(sheets, Sheet{Page: len(sheets) + 2, Items: rest[start:end]})
+	}
+	pages := len(sheets) + 1
+	for i := range sheets {
+		sheets[i].Pages = pages
+	}
+	return firstPage, sheets
+}
+
+const pageBreak = `<w:p><w:r><w:br w:type="page"/></w:r></w:p>`
+
+var rxTableTag = regexp.MustCompile(`</?w:(tbl|tr)[\s>]`)
+
+// sheetTable is the continuation table of a template: a table of the document
+// body whose only fields are in its last row, a {{Sheets_...}} row.
+type sheetTable struct {
+	index      int
+	headerRows int
+}
+
+// elementSpans returns the start and end offsets of the tbl or tr elements of
+// document that are tableDepth tables deep: 0 for the tables of the body, 1
+// for the rows of a table.
+func elementSpans(document string, name string, tableDepth int) [][2]int {
+	var spans [][2]int
+	depth, start := 0, -1
+	for _, match := range rxTableTag.FindAllStringSubmatchIndex(document, -1) {
+		closing := document[match[0]+1] == '/'
+		tag := document[match[2]:match[3]]
+		if tag == "tbl" && closing {
+			depth--
+		}
+		if tag == name && depth == tableDepth {
+			if !closing {
+				start = match[0]
+			} else if start >= 0 {
+				spans = append(spans, [2]int{start, match[1]})
+				start = -1
+			}
+		}
+		if tag == "tbl" && !closing {
+			depth++
+		}
+	}
+	return spans
+}
+
+// findSheetTable locates the continuation table of the template document.
+func findSheetTable(document string) (sheetTable, bool) {
+	for i, table := range elementSpans(document, "tbl", 0) {
+		text := document[table[0]:table[1]]
+		rows := elementSpans(text, "tr", 1)
+		fields := make([][]string, len(rows))
+		for j, row := range rows {
+			rowText := rxXMLTag.ReplaceAllString(text[row[0]:row[1]], "")
+			for _, match := range rxPlaceholder.FindAllStringSubmatch(rowText, -1) {
+				fields[j] = append(fields[j], match[1])
+			}
+		}
+		last := len(rows) - 1
+		if last < 0 || len(fields[last]) == 0 || slices.ContainsFunc(fields[:last], func(row []string) bool { return len(row) > 0 }) {
+			continue
+		}
+		if !slices.ContainsFunc(fields[last], func(field string) bool { return !strings.HasPrefix(field, "Sheets_") }) {
+			return sheetTable{index: i, headerRows: last}, true
+		}
+	}
+	return sheetTable{}, false
+}
+
+// breakSheets splits the rendered continuation table into one table per
+// sheet, each on a new page and with the header rows repeated, so that every
+// sheet of Pages is a printed page. Without continuation sheets the table is
+// left out. The document is returned unchanged when the rows do not match the
+// sheets.
+func breakSheets(document string, table sheetTable, sheets []Sheet) string {
+	tables := elementSpans(document, "tbl", 0)
+	if table.index >= len(tables) {
+		return document
+	}
+	span := tables[table.index]
+	text := document[span[0]:span[1]]
+	rows := elementSpans(text, "tr", 1)
+	itemCount := 0
+	for _, sheet := range sheets {
+		itemCount += len(sheet.Items)
+	}
+	// Without sheets the template row is left empty rather than removed.
+	if itemCount == 0 {
+		return document[:span[0]] + document[span[1]:]
+	}
+	if len(rows) != table.headerRows+itemCount {
+		return document
+	}
+	prefix := text[:rows[0][0]]
+	suffix := text[rows[len(rows)-1][1]:]
+	header := ""
+	if table.headerRows > 0 {
+		header = text[rows[0][0]:rows[table.headerRows-1][1]]
+	}
+	var result strings.Builder
+	next := table.headerRows
+	for _, sheet := range sheets {
+		if len(sheet.Items) == 0 {
+			continue
+		}
+		first, last := rows[next], rows[next+len(sheet.Items)-1]
+		result.WriteString(pageBreak + prefix + header + text[first[0]:last[1]] + suffix)
+		next += len(sheet.Items)
+	}
+	return document[:span[0]] + result.String() + document[span[1]:]
+}
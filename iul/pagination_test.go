@@ -0,0 +1,50 @@
+package iul
+
+import (
+	"strconv"
+	"testing"
+)
+
+func numberedItems(count int) []ItemRow {
+	items := make([]ItemRow, count)
+	for i := range items {
+		items[i].FileName = strconv.Itoa(i + 1)
+	}
+	return items
+}
+
+func TestPaginateItems(t *testing.T) {
+	tests := []struct {
+		name       string
+		items      int
+		pagination Pagination
+		firstPage  int
+		sheetSizes []int
+	}{
+		{name: "disabled", items: 10, pagination: Pagination{}, firstPage: 10},
+		{name: "fits first page", items: 5, pagination: Pagination{FirstPageRows: 5, ContinuationPageRows: 2}, firstPage: 5},
+		{name: "continuation sheets", items: 10, pagination: Pagination{FirstPageRows: 3, ContinuationPageRows: 4}, firstPage: 3, sheetSizes: []int{4, 3}},
+		{name: "unlimited continuation", items: 10, pagination: Pagination{FirstPageRows: 3}, firstPage: 3, sheetSizes: []int{7}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			firstPage, sheets := paginateItems(numberedItems(test.items), test.pagination)
+			if len(firstPage) != test.firstPage {
+				t.Errorf("first page has %d rows, want %d", len(firstPage), test.firstPage)
+			}
+			if len(sheets) != len(test.sheetSizes) {
+				t.Fatalf("got %d continuation sheets, want %d", len(sheets), len(test.sheetSizes))
+			}
+			next := test.firstPage + 1
+			for i, sheet := range sheets {
+				if len(sheet.Items) != test.sheetSizes[i] || sheet.Page != i+2 || sheet.Pages != len(sheets)+1 {
+					t.Errorf("sheet %d = page %d of %d with %d rows", i, sheet.Page, sheet.Pages, len(sheet.Items))
+				}
+				if sheet.Items[0].FileName != strconv.Itoa(next) {
+					t.Errorf("sheet %d starts with item %s, want %d", i, sheet.Items[0].FileName, next)
+				}
+				next += len(sheet.Items)
+			}
+		})
+	}
+}
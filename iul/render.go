@@ -0,0 +1,178 @@
+package iul
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+)
+
+// ItemOptions controls which auxiliary files are listed as regular item rows
+// next to the scanned folder contents.
+type ItemOptions struct {
+	IncludeWorkbook bool
+	IncludeTemplate bool
+}
+
+type RenderOptions struct {
+	// TemplatePath is the template document, the built-in template when empty.
+	TemplatePath string
+	OutputPath   string
+	// KeepBackup moves a previous output document and its manifest aside
+	// instead of overwriting them.
+	KeepBackup bool
+	Items      ItemOptions
+	Pagination Pagination
+	Profile    FormatProfile
+	Timestamps TimestampOptions
+	// Previous is the manifest of the previous issue the files are compared
+	// with for {{Items_Status}}, StatusNames the values written for each
+	// status, DefaultStatusNames when empty.
+	Previous    *Manifest
+	StatusNames map[FileStatus]string
+}
+
+type RenderData struct {
+	Items    []ItemRow
+	Sheets   []Sheet
+	Page     int
+	Pages    int
+	Excel    ItemRow
+	Template ItemRow
+	Control  map[string]string
+	Authors  []Author
+	// Removed lists the files of the previous issue that are gone.
+	Removed []ItemRow
+}
+
+// NewRenderData builds the template context for the project. The template
+// checksum is returned as well so that it can be recorded in the manifest.
+func NewRenderData(ctx context.Context, project *Project, options RenderOptions) (*RenderData, CheckedFile, error) {
+	profile := options.Profile
+	renderData := new(RenderData)
+	var items []ItemRow
+	for _, file := range project.Files {
+		items = append(items, profile.FormatFile(file))
+	}
+	if options.Previous != nil {
+		statusNames := options.StatusNames
+		if len(statusNames) == 0 {
+			statusNames = DefaultStatusNames
+		}
+		for i, change := range CompareFiles(options.Previous.Files, project.Files) {
+			if change.Status == StatusRemoved {
+				removed := profile.FormatFile(change.File)
+				removed.Status = statusNames[change.Status]
+				renderData.Removed = append(renderData.Removed, removed)
+				continue
+			}
+			items[i].Status = statusNames[change.Status]
+		}
+	}
+	renderData.Excel = profile.FormatFile(project.Workbook)
+	if options.Items.IncludeWorkbook && project.Workbook.FileName != "" {
+		items = append(items, renderData.Excel)
+	}
+	var templateChecksum CheckedFile
+	if options.Items.IncludeTemplate {
+		var err error
+		templateChecksum, err = checksumTemplate(ctx, options.TemplatePath, options.Timestamps)
+		if err != nil {
+			return nil, CheckedFile{}, err
+		}
+		renderData.Template = profile.FormatFile(templateChecksum)
+		items = append(items, renderData.Template)
+	}
+	renderData.Items, renderData.Sheets = paginateItems(items, options.Pagination)
+	renderData.Page = 1
+	renderData.Pages = len(renderData.Sheets) + 1
+	renderData.Control = profile.FormatControl(project.Control)
+	renderData.Authors = project.Authors
+	return renderData, templateChecksum, nil
+}
+
+// Render fills the template with the project data, saves the document to
+// options.OutputPath and writes the manifest next to it.
+func Render(ctx context.Context, project *Project, options RenderOptions) error {
+	template, err := openTemplate(options.TemplatePath)
+	if err != nil {
+		return err
+	}
+	renderData, templateChecksum, err := NewRenderData(ctx, project, options)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := template.RenderTemplate(renderData); err != nil {
+		return err
+	}
+	var document bytes.Buffer
+	if err := template.Write(&document); err != nil {
+		return err
+	}
+	rendered, err := paginateDocument(document.Bytes(), options.TemplatePath, renderData.Sheets)
+	if err != nil {
+		return err
+	}
+	if options.KeepBackup {
+		if _, err := BackupOutput(options.OutputPath); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(options.OutputPath, rendered, 0644); err != nil {
+		return err
+	}
+	manifest := NewManifest(project, templateChecksum, options)
+	return manifest.Save(ManifestPath(options.OutputPath))
+}
+
+// paginateDocument puts every continuation sheet of the rendered document on
+// its own page, see breakSheets. Documents of templates without a
+// continuation table are returned as they are.
+func paginateDocument(docx []byte, templatePath string, sheets []Sheet) ([]byte, error) {
+	templateData, err := templateDocument(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	table, ok := findSheetTable(string(templateData))
+	if !ok {
+		return docx, nil
+	}
+	reader, err := zip.NewReader(bytes.NewReader(docx), int64(len(docx)))
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	writer := zip.NewWriter(&output)
+	for _, zipFile := range reader.File {
+		if zipFile.Name != documentPart {
+			if err := writer.Copy(zipFile); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		part, err := zipFile.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(part)
+		part.Close()
+		if err != nil {
+			return nil, err
+		}
+		entry, err := writer.CreateHeader(&zip.FileHeader{Name: zipFile.Name, Method: zip.Deflate})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(entry, breakSheets(string(data), table, sheets)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return output.Bytes(), nil
+}
@@ -0,0 +1,250 @@
+package iul
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"github.com/AndyGreenwell94/docxt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const testTemplatePath = "template.docx"
+
+func goldenRenderData() *RenderData {
+	items := []ItemRow{
+		{FileName: "01_ПЗ.pdf", Checksum: "3610A686", FileSize: "5", CreatedAt: "2024.03.01_10:30"},
+		{FileName: "02_ИОС.pdf", Checksum: "1F2E3D4C", FileSize: "1024", CreatedAt: "2024.03.02_11:00"},
+		{FileName: "03_Чертежи & схемы.dwg", Checksum: "ABCDEF", FileSize: "1048576", CreatedAt: "2024.03.03_12:15"},
+	}
+	return &RenderData{
+		Items: items,
+		Page:  1,
+		Pages: 1,
+		Excel: ItemRow{FileName: "Ведомость.xlsx", Checksum: "DEADBEEF", FileSize: "2048", CreatedAt: "2024.02.28_09:00"},
+		Control: map[string]string{
+			"A1": "Шифр",
+			"F7": "123-2024-ИУЛ",
+		},
+		Authors: []Author{
+			{Title: "Разраб.", Name: "Иванов"},
+			{Title: "Проверил", Name: "Петров"},
+		},
+	}
+}
+
+// canonicalXML rewrites the document as a plain token stream with sorted
+// attributes, since docxt writes the root namespace declarations in map order.
+func canonicalXML(data []byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			return buffer.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			attributes := make([]string, 0, len(token.Attr))
+			for _, attribute := range token.Attr {
+				attributes = append(attributes, qualifiedName(attribute.Name)+"="+attribute.Value)
+			}
+			sort.Strings(attributes)
+			buffer.WriteString("<" + qualifiedName(token.Name))
+			for _, attribute := range attributes {
+				buffer.WriteString(" " + attribute)
+			}
+			buffer.WriteString(">\n")
+		case xml.EndElement:
+			buffer.WriteString("</" + qualifiedName(token.Name) + ">\n")
+		case xml.CharData:
+			if text := strings.TrimSpace(string(token)); text != "" {
+				buffer.WriteString(text + "\n")
+			}
+		}
+	}
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+func renderedDocument(t *testing.T, data *RenderData) []byte {
+	t.Helper()
+	return renderedPart(t, testTemplatePath, data, "word/document.xml")
+}
+
+// renderedPart renders the template the way Render does and returns the
+// named part in canonical form.
+func renderedPart(t *testing.T, templatePath string, data *RenderData, name string) []byte {
+	t.Helper()
+	template, err := docxt.OpenTemplate(templatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := template.RenderTemplate(data); err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	if err := template.Write(&output); err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := paginateDocument(output.Bytes(), templatePath, data.Sheets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(rendered), int64(len(rendered)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := archive.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	part, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := canonicalXML(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return canonical
+}
+
+// checkGolden compares got with the golden file, rewriting it with -update.
+func checkGolden(t *testing.T, got []byte, name string) {
+	t.Helper()
+	goldenPath := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(goldenPath, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("%s (run go test ./iul -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("rendered part differs from %s; rerun with -update after checking the change", goldenPath)
+	}
+}
+
+func TestRenderTemplateGolden(t *testing.T) {
+	checkGolden(t, renderedDocument(t, goldenRenderData()), "document.golden.xml")
+}
+
+// Render fills the document body only, so header placeholders are kept as
+// they are and not reported by TemplateFields.
+func TestRenderTemplateHeaderGolden(t *testing.T) {
+	source, err := zip.OpenReader(testTemplatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+	parts := make(map[string]string)
+	for _, zipFile := range source.File {
+		part, err := zipFile.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(part)
+		part.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[zipFile.Name] = string(data)
+	}
+	parts["word/header1.xml"] = strings.Replace(parts["word/header1.xml"], "Инв. № подл.", "{{Control.A1}}", 1)
+	templatePath := filepath.Join(t.TempDir(), "header.docx")
+	writeDocx(t, templatePath, parts)
+
+	header := renderedPart(t, templatePath, goldenRenderData(), "word/header1.xml")
+	checkGolden(t, header, "header.golden.xml")
+	if !bytes.Contains(header, []byte("{{Control.A1}}")) {
+		t.Error("rendered header lost its placeholder")
+	}
+	fields, err := TemplateFields(templatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range fields {
+		if field == "Control.A1" {
+			t.Errorf("TemplateFields() = %v, lists the header field", fields)
+		}
+	}
+}
+
+func TestRenderTemplateIsDeterministic(t *testing.T) {
+	first := renderedDocument(t, goldenRenderData())
+	second := renderedDocument(t, goldenRenderData())
+	if !bytes.Equal(first, second) {
+		t.Error("rendering the same data twice produced different documents")
+	}
+}
+
+// documentText returns the text lines of a canonical document.
+func documentText(document []byte) string {
+	var lines []string
+	for _, line := range strings.Split(string(document), "\n") {
+		if !strings.HasPrefix(line, "<") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestRenderTemplatePages(t *testing.T) {
+	data := goldenRenderData()
+	data.Items, data.Sheets = paginateItems(data.Items, Pagination{FirstPageRows: 1, ContinuationPageRows: 1})
+	data.Pages = len(data.Sheets) + 1
+	document := renderedDocument(t, data)
+	checkGolden(t, document, "sheets.golden.xml")
+	text := documentText(document)
+	if strings.Contains(text, "{{") {
+		t.Error("rendered document keeps template fields")
+	}
+	if !strings.Contains(text, "\nЛист 1. Листов 3\n") {
+		t.Error("rendered document has no Page and Pages")
+	}
+	// Every continuation sheet is a table of its own on a new page, headed
+	// by the continuation title and holding the rows of that sheet only.
+	if got := bytes.Count(document, []byte("<w:br w:type=page>")); got != len(data.Sheets) {
+		t.Errorf("rendered document has %d page breaks, want %d", got, len(data.Sheets))
+	}
+	sheets := strings.Split(text, "Продолжение информационно-удостоверяющего листа")
+	if len(sheets) != data.Pages {
+		t.Fatalf("rendered document has %d continuation sheets, want %d", len(sheets)-1, data.Pages-1)
+	}
+	for i, want := range []string{"\n2\n02_ИОС.pdf\n1F2E3D4C\n", "\n3\n03_Чертежи & схемы.dwg\nABCDEF\n"} {
+		if !strings.Contains(sheets[i+1], want) {
+			t.Errorf("continuation sheet %d has no row %q", i+2, want)
+		}
+	}
+	if strings.Contains(sheets[1], "02_ИОС.pdf") && strings.Contains(sheets[1], "03_Чертежи") {
+		t.Error("continuation sheet 2 holds the rows of sheet 3")
+	}
+
+	document = renderedDocument(t, goldenRenderData())
+	text = documentText(document)
+	if strings.Contains(text, "{{") {
+		t.Error("rendered document without continuation sheets keeps template fields")
+	}
+	if strings.Contains(text, "Продолжение") || bytes.Contains(document, []byte("w:type=page")) {
+		t.Error("rendered document without continuation sheets has a continuation table")
+	}
+}
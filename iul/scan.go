@@ -0,0 +1,157 @@
+package iul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type ScanOptions struct {
+	Timestamps TimestampOptions
+	// Exclude lists paths that are never listed, such as the output document
+	// and its manifest when they are written into the scanned folder.
+	Exclude []string
+	// Output matches the versions of the output document, which are never
+	// listed either.
+	Output OutputMatcher
+	// Cache serves the checksums of unchanged files, ForceRehash reads every
+	// file again and refreshes the cache.
+	Cache       *ChecksumCache
+	ForceRehash bool
+	// ExpandArchives lists the entries of ZIP and TAR archives right after
+	// the archive itself.
+	ExpandArchives bool
+}
+
+// isExcluded also skips detached signatures, which accompany the listed files
+// rather than being listed themselves, and the manifests and backups written
+// with the output documents.
+func isExcluded(dir string, name string, options ScanOptions) bool {
+	if isSignature(name) || strings.HasSuffix(name, ManifestSuffix) || isBackup(name) {
+		return true
+	}
+	filePath, err := filepath.Abs(filepath.Join(dir, name))
+	if err != nil {
+		return false
+	}
+	if options.Output.Match(filePath) {
+		return true
+	}
+	for _, excludedPath := range options.Exclude {
+		excludedPath, err = filepath.Abs(excludedPath)
+		if err == nil && excludedPath == filePath {
+			return true
+		}
+	}
+	return false
+}
+
+// checkFile checksums dir/name and, for archives when enabled, its entries.
+func checkFile(ctx context.Context, dir string, name string, options ScanOptions) ([]CheckedFile, error) {
+	checkedFile, err := cachedChecksumFile(ctx, dir, name, options)
+	if err != nil {
+		return nil, err
+	}
+	if !options.ExpandArchives || !IsArchive(name) {
+		return []CheckedFile{checkedFile}, nil
+	}
+	entries, err := ReadArchive(ctx, dir, name, options.Timestamps)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return append([]CheckedFile{checkedFile}, entries...), nil
+}
+
+// Scan checksums every file in dir in directory order.
+func Scan(ctx context.Context, dir string, options ScanOptions) ([]CheckedFile, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var checkedFiles []CheckedFile
+	for _, file := range files {
+		if isExcluded(dir, file.Name(), options) {
+			continue
+		}
+		checkedFile, err := checkFile(ctx, dir, file.Name(), options)
+		if err != nil {
+			return nil, err
+		}
+		checkedFiles = append(checkedFiles, checkedFile...)
+	}
+	return checkedFiles, nil
+}
+
+// Rescan checksums only the named files of dir. Files that no longer exist are
+// returned as removed; excluded files and subdirectories are ignored.
+func Rescan(ctx context.Context, dir string, names []string, options ScanOptions) ([]CheckedFile, []string, error) {
+	var changed []CheckedFile
+	var removed []string
+	for _, name := range names {
+		if isExcluded(dir, name, options) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			removed = append(removed, name)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if info.IsDir() {
+			continue
+		}
+		checkedFile, err := checkFile(ctx, dir, name, options)
+		if errors.Is(err, fs.ErrNotExist) {
+			removed = append(removed, name)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		changed = append(changed, checkedFile...)
+	}
+	return changed, removed, nil
+}
+
+// StaleFiles compares the listing of dir with the checked files by name, size
+// and modification time without reading the contents, and returns the names
+// that were added, removed or modified since they were checked.
+func StaleFiles(dir string, files []CheckedFile, options ScanOptions) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	checked := make(map[string]CheckedFile, len(files))
+	for _, file := range files {
+		if file.Archive == "" {
+			checked[file.FileName] = file
+		}
+	}
+	var stale []string
+	for _, entry := range entries {
+		if entry.IsDir() || isExcluded(dir, entry.Name(), options) {
+			continue
+		}
+		file, ok := checked[entry.Name()]
+		delete(checked, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		if !ok || info.Size() != file.FileSize || !info.ModTime().Equal(file.ModTime) {
+			stale = append(stale, entry.Name())
+		}
+	}
+	for _, file := range files {
+		if _, ok := checked[file.FileName]; ok && file.Archive == "" {
+			stale = append(stale, file.FileName)
+		}
+	}
+	return stale, nil
+}
@@ -0,0 +1,186 @@
+package iul
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"github.com/digitorus/pkcs7"
+	"io"
+	"os"
+	"path/filepath"
+	"software.sslmate.com/src/go-pkcs12"
+	"strings"
+)
+
+// SignatureExtension is appended to the signed file name to get the name of
+// its detached signature.
+const SignatureExtension = ".sig"
+
+// Signer creates detached CMS (PKCS#7) signatures. The PKCS#12 signer is the
+// only implementation for now; a ГОСТ crypto provider can be plugged in by
+// implementing Signer and SignatureVerifier.
+type Signer interface {
+	// Sign returns the DER encoded detached signature of the content.
+	Sign(ctx context.Context, content io.Reader) ([]byte, error)
+}
+
+// SignatureVerifier checks a detached signature and returns the signer
+// certificate.
+type SignatureVerifier interface {
+	Verify(ctx context.Context, content io.Reader, signature []byte) (*x509.Certificate, error)
+}
+
+// PKCS12Signer signs with the key and certificate chain of a PKCS#12 file.
+type PKCS12Signer struct {
+	key         crypto.PrivateKey
+	certificate *x509.Certificate
+	chain       []*x509.Certificate
+}
+
+func LoadPKCS12Signer(path string, password string) (*PKCS12Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, certificate, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, err
+	}
+	return &PKCS12Signer{key: key, certificate: certificate, chain: chain}, nil
+}
+
+func (s *PKCS12Signer) Certificate() *x509.Certificate {
+	return s.certificate
+}
+
+// Sign reads the whole content into memory, as the CMS encoder needs it.
+func (s *PKCS12Signer) Sign(ctx context.Context, content io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(contextReader{ctx: ctx, reader: content})
+	if err != nil {
+		return nil, err
+	}
+	signedData, err := pkcs7.NewSignedData(data)
+	if err != nil {
+		return nil, err
+	}
+	signedData.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	if err := signedData.AddSignerChain(s.certificate, s.key, s.chain, pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, err
+	}
+	signedData.Detach()
+	return signedData.Finish()
+}
+
+// PKCS7Verifier checks CMS signatures. With nil Roots only the signature
+// itself is checked, otherwise the signer certificate must chain to one of
+// the roots.
+type PKCS7Verifier struct {
+	Roots *x509.CertPool
+}
+
+func (v PKCS7Verifier) Verify(ctx context.Context, content io.Reader, signature []byte) (*x509.Certificate, error) {
+	signedData, err := pkcs7.Parse(signature)
+	if err != nil {
+		return nil, err
+	}
+	signedData.Content, err = io.ReadAll(contextReader{ctx: ctx, reader: content})
+	if err != nil {
+		return nil, err
+	}
+	if err := signedData.VerifyWithChain(v.Roots); err != nil {
+		return nil, err
+	}
+	return signedData.GetOnlySigner(), nil
+}
+
+// SignaturePath returns the detached signature location for a file.
+func SignaturePath(filePath string) string {
+	return filePath + SignatureExtension
+}
+
+func isSignature(fileName string) bool {
+	return strings.HasSuffix(strings.ToLower(fileName), SignatureExtension)
+}
+
+// SignFile writes the detached signature of filePath next to it.
+func SignFile(ctx context.Context, signer Signer, filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	signature, err := signer.Sign(ctx, file)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(SignaturePath(filePath), signature, 0644)
+}
+
+// SignatureTargets lists the files signed for a render: every listed file of
+// the folder, the rendered document and the manifest.
+func SignatureTargets(manifestPath string) ([]string, error) {
+	manifest, err := LoadManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var targets []string
+	for _, file := range manifest.Files {
+		if file.Archive == "" {
+			targets = append(targets, filepath.Join(manifest.Folder, file.FileName))
+		}
+	}
+	return append(targets, manifest.Output, manifestPath), nil
+}
+
+// SignManifest signs every target of the manifest and returns the signed paths.
+func SignManifest(ctx context.Context, signer Signer, manifestPath string) ([]string, error) {
+	targets, err := SignatureTargets(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range targets {
+		if err := SignFile(ctx, signer, target); err != nil {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// SignatureCheck is the result of checking the signature of one file.
+type SignatureCheck struct {
+	Path   string
+	Signer *x509.Certificate
+	Err    error
+}
+
+// VerifySignatures checks the detached signature of every target of the
+// manifest. A missing signature is reported with fs.ErrNotExist.
+func VerifySignatures(ctx context.Context, verifier SignatureVerifier, manifestPath string) ([]SignatureCheck, error) {
+	targets, err := SignatureTargets(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	checks := make([]SignatureCheck, len(targets))
+	for i, target := range targets {
+		checks[i] = SignatureCheck{Path: target}
+		checks[i].Signer, checks[i].Err = verifyFileSignature(ctx, verifier, target)
+		if errors.Is(checks[i].Err, context.Canceled) {
+			return nil, checks[i].Err
+		}
+	}
+	return checks, nil
+}
+
+func verifyFileSignature(ctx context.Context, verifier SignatureVerifier, filePath string) (*x509.Certificate, error) {
+	signature, err := os.ReadFile(SignaturePath(filePath))
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return verifier.Verify(ctx, file, signature)
+}
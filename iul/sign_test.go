@@ -0,0 +1,137 @@
+package iul
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"software.sslmate.com/src/go-pkcs12"
+	"testing"
+	"time"
+)
+
+func newSelfSignedPKCS12(t *testing.T, dir string, password string) (string, *x509.Certificate) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Иванов И.И."},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := pkcs12.Modern.Encode(key, certificate, nil, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "key.p12")
+	if err := os.WriteFile(keyPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return keyPath, certificate
+}
+
+func TestSignAndVerifyManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := writeTestManifest(t, dir)
+	keyPath, certificate := newSelfSignedPKCS12(t, t.TempDir(), "secret")
+
+	if _, err := LoadPKCS12Signer(keyPath, "wrong"); err == nil {
+		t.Error("LoadPKCS12Signer() accepted a wrong password")
+	}
+	signer, err := LoadPKCS12Signer(keyPath, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := SignManifest(context.Background(), signer, manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signed) != 4 {
+		t.Fatalf("SignManifest() signed %v, want two files, the document and the manifest", signed)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate)
+	for _, verifier := range []PKCS7Verifier{{}, {Roots: roots}} {
+		checks, err := VerifySignatures(context.Background(), verifier, manifestPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, check := range checks {
+			if check.Err != nil {
+				t.Errorf("%s: %v", check.Path, check.Err)
+			} else if check.Signer.Subject.CommonName != "Иванов И.И." {
+				t.Errorf("%s signed by %s", check.Path, check.Signer.Subject)
+			}
+		}
+	}
+
+	// Signatures are not listed as files of the folder.
+	files, err := Scan(context.Background(), filepath.Join(dir, "folder"), ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("Scan() listed %v after signing", fileNames(files))
+	}
+
+	writeFile(t, filepath.Join(dir, "folder"), "a.pdf", "tampered")
+	if err := os.Remove(SignaturePath(filepath.Join(dir, "folder", "b.pdf"))); err != nil {
+		t.Fatal(err)
+	}
+	checks, err := VerifySignatures(context.Background(), PKCS7Verifier{}, manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checks[0].Err == nil {
+		t.Error("VerifySignatures() accepted a tampered file")
+	}
+	if !errors.Is(checks[1].Err, os.ErrNotExist) {
+		t.Errorf("VerifySignatures() error = %v for a missing signature, want ErrNotExist", checks[1].Err)
+	}
+}
+
+func TestVerifyRejectsUntrustedSigner(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := writeTestManifest(t, dir)
+	keyPath, _ := newSelfSignedPKCS12(t, t.TempDir(), "")
+	_, otherCertificate := newSelfSignedPKCS12(t, t.TempDir(), "")
+	signer, err := LoadPKCS12Signer(keyPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignManifest(context.Background(), signer, manifestPath); err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(otherCertificate)
+	checks, err := VerifySignatures(context.Background(), PKCS7Verifier{Roots: roots}, manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, check := range checks {
+		if check.Err == nil {
+			t.Errorf("%s verified against an unrelated root", check.Path)
+		}
+	}
+}
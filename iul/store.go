@@ -0,0 +1,77 @@
+package iul
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	projectStoreDirName = "projects"
+	projectSettingsFile = "settings.json"
+	projectKeyLength    = 16
+)
+
+// ProjectSettings are the choices remembered for a project folder.
+type ProjectSettings struct {
+	Folder   string `json:"folder"`
+	Template string `json:"template,omitempty"`
+}
+
+// ProjectStore keeps per-project data in a directory per project folder,
+// named by a hash of the folder path.
+type ProjectStore struct {
+	dir string
+}
+
+// DefaultProjectStoreDir returns the store location in the user config dir.
+func DefaultProjectStoreDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cacheDirName, projectStoreDirName), nil
+}
+
+func NewProjectStore(dir string) *ProjectStore {
+	return &ProjectStore{dir: dir}
+}
+
+// ProjectDir returns the store directory of the project folder.
+func (s *ProjectStore) ProjectDir(folder string) string {
+	sum := sha256.Sum256([]byte(absolutePath(folder)))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])[:projectKeyLength])
+}
+
+// Load returns the remembered settings, or empty settings for a new project.
+func (s *ProjectStore) Load(folder string) (ProjectSettings, error) {
+	settings := ProjectSettings{Folder: absolutePath(folder)}
+	data, err := os.ReadFile(filepath.Join(s.ProjectDir(folder), projectSettingsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return settings, err
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+func (s *ProjectStore) Save(settings ProjectSettings) error {
+	projectDir := s.ProjectDir(settings.Folder)
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		return err
+	}
+	settings.Folder = absolutePath(settings.Folder)
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(projectDir, projectSettingsFile), data, 0644)
+}
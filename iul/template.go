@@ -0,0 +1,85 @@
+package iul
+
+import (
+	"context"
+	_ "embed"
+	"github.com/AndyGreenwell94/docxt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+)
+
+// BuiltinTemplateName is the file name the built-in template is listed and
+// exported under.
+const BuiltinTemplateName = "template.docx"
+
+// BuiltinTemplate is the default template compiled into the binary. It is used
+// whenever no template file is chosen.
+//
+//go:embed template.docx
+var BuiltinTemplate []byte
+
+// ExportBuiltinTemplate writes the built-in template to path so that it can be
+// customised and chosen as a regular template file.
+func ExportBuiltinTemplate(path string) error {
+	return os.WriteFile(path, BuiltinTemplate, 0644)
+}
+
+// builtinTemplatePath returns a file holding the built-in template. docxt only
+// opens templates from disk and keeps the file open until the document is
+// saved, so the template is written once to the user cache dir, named by its
+// checksum, instead of to a temporary file per render.
+func builtinTemplatePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	templatePath := filepath.Join(dir, cacheDirName, "template-"+formatChecksum(crc32.ChecksumIEEE(BuiltinTemplate))+TemplateExtension)
+	if info, err := os.Stat(templatePath); err == nil && info.Size() == int64(len(BuiltinTemplate)) {
+		return templatePath, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(templatePath), 0755); err != nil {
+		return "", err
+	}
+	temporaryPath := templatePath + ".tmp"
+	if err := ExportBuiltinTemplate(temporaryPath); err != nil {
+		return "", err
+	}
+	if err := os.Rename(temporaryPath, templatePath); err != nil {
+		return "", err
+	}
+	return templatePath, nil
+}
+
+// resolveTemplatePath is templatePath, or the built-in template when empty.
+func resolveTemplatePath(templatePath string) (string, error) {
+	if templatePath != "" {
+		return templatePath, nil
+	}
+	return builtinTemplatePath()
+}
+
+func openTemplate(templatePath string) (*docxt.DocxTemplateFile, error) {
+	templatePath, err := resolveTemplatePath(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	return docxt.OpenTemplate(templatePath)
+}
+
+// checksumTemplate checksums the template file, listing the built-in template
+// under BuiltinTemplateName.
+func checksumTemplate(ctx context.Context, templatePath string, timestamps TimestampOptions) (CheckedFile, error) {
+	resolvedPath, err := resolveTemplatePath(templatePath)
+	if err != nil {
+		return CheckedFile{}, err
+	}
+	checkedFile, err := ChecksumPath(ctx, resolvedPath, timestamps)
+	if err != nil {
+		return CheckedFile{}, err
+	}
+	if templatePath == "" {
+		checkedFile.FileName = BuiltinTemplateName
+	}
+	return checkedFile, nil
+}
@@ -0,0 +1,71 @@
+package iul
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportBuiltinTemplate(t *testing.T) {
+	want, err := os.ReadFile(testTemplatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportPath := filepath.Join(t.TempDir(), "exported.docx")
+	if err := ExportBuiltinTemplate(exportPath); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(exportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("exported template differs from the embedded template.docx")
+	}
+}
+
+func TestRenderWithBuiltinTemplate(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	writeFile(t, dir, "a.pdf", "a")
+	files, err := Scan(context.Background(), dir, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputPath := filepath.Join(t.TempDir(), "result.docx")
+	options := RenderOptions{
+		OutputPath: outputPath,
+		Items:      ItemOptions{IncludeTemplate: true},
+		Profile:    FindFormatProfile(DefaultFormatProfile),
+	}
+	if err := Render(context.Background(), &Project{Folder: dir, Files: files}, options); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(outputPath); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := LoadManifest(ManifestPath(outputPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.TemplateFile == nil || manifest.TemplateFile.FileName != BuiltinTemplateName {
+		t.Errorf("manifest template file = %+v, want %s", manifest.TemplateFile, BuiltinTemplateName)
+	}
+	embedded, err := ChecksumPath(context.Background(), testTemplatePath, TimestampOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.TemplateFile != nil && manifest.TemplateFile.Checksum != embedded.Checksum {
+		t.Errorf("template checksum = %s, want %s", manifest.TemplateFile.Checksum, embedded.Checksum)
+	}
+
+	fields, err := TemplateFields("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) == 0 {
+		t.Error("TemplateFields(\"\") found no fields in the built-in template")
+	}
+}
@@ -0,0 +1,171 @@
+package iul
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	IssueDateLayout    = "02.01.2006"
+	metadataScanWindow = 1 << 20
+)
+
+// TimestampSource selects where the "created" date of a checked file comes from.
+type TimestampSource string
+
+const (
+	TimestampModified  TimestampSource = "modified"
+	TimestampBirth     TimestampSource = "birth"
+	TimestampIssueDate TimestampSource = "issue_date"
+	TimestampMetadata  TimestampSource = "metadata"
+)
+
+var TimestampSources = []TimestampSource{TimestampModified, TimestampBirth, TimestampIssueDate, TimestampMetadata}
+
+type TimestampOptions struct {
+	Source    TimestampSource
+	IssueDate time.Time
+}
+
+var (
+	rxPDFCreationDate = regexp.MustCompile(`/CreationDate\s*\(D:(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?([Zz+\-])?(\d{2})?'?(\d{2})?'?\)`)
+	rxXMPCreateDate   = regexp.MustCompile(`<xmp:CreateDate>([^<]+)</xmp:CreateDate>`)
+)
+
+var officeExtensions = []string{".docx", ".xlsx", ".pptx", ".docm", ".xlsm"}
+
+// resolveTimestamp returns the timestamp for the file together with the
+// source it was actually taken from. Sources that are unavailable for the
+// file fall back to the modification time.
+func resolveTimestamp(filePath string, info os.FileInfo, options TimestampOptions) (time.Time, TimestampSource) {
+	switch options.Source {
+	case TimestampBirth:
+		if created, ok := birthTime(filePath, info); ok {
+			return created, TimestampBirth
+		}
+	case TimestampIssueDate:
+		if !options.IssueDate.IsZero() {
+			return options.IssueDate, TimestampIssueDate
+		}
+	case TimestampMetadata:
+		if created, ok := metadataTime(filePath); ok {
+			return created, TimestampMetadata
+		}
+	}
+	return info.ModTime(), TimestampModified
+}
+
+func metadataTime(filePath string) (time.Time, bool) {
+	extension := strings.ToLower(filepath.Ext(filePath))
+	if extension == ".pdf" {
+		return pdfCreationDate(filePath)
+	}
+	for _, officeExtension := range officeExtensions {
+		if extension == officeExtension {
+			return officeCreationDate(filePath)
+		}
+	}
+	return time.Time{}, false
+}
+
+func pdfCreationDate(filePath string) (time.Time, bool) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return time.Time{}, false
+	}
+	// The document information dictionary and XMP packet are usually near the
+	// start or the end of the file, so only those regions are scanned.
+	var chunks [][]byte
+	head := make([]byte, min(info.Size(), metadataScanWindow))
+	if _, err := io.ReadFull(file, head); err != nil {
+		return time.Time{}, false
+	}
+	chunks = append(chunks, head)
+	if info.Size() > metadataScanWindow {
+		tail := make([]byte, min(info.Size()-metadataScanWindow, metadataScanWindow))
+		if _, err := file.ReadAt(tail, info.Size()-int64(len(tail))); err != nil && err != io.EOF {
+			return time.Time{}, false
+		}
+		chunks = append(chunks, tail)
+	}
+	for _, chunk := range chunks {
+		if match := rxPDFCreationDate.FindSubmatch(chunk); match != nil {
+			if created, ok := parsePDFDate(match); ok {
+				return created, true
+			}
+		}
+		if match := rxXMPCreateDate.FindSubmatch(chunk); match != nil {
+			if created, err := time.Parse(time.RFC3339, strings.TrimSpace(string(match[1]))); err == nil {
+				return created, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func parsePDFDate(match [][]byte) (time.Time, bool) {
+	parts := []string{"0000", "01", "01", "00", "00", "00"}
+	for i := range parts {
+		if len(match[i+1]) > 0 {
+			parts[i] = string(match[i+1])
+		}
+	}
+	value := strings.Join(parts, "")
+	location := time.Local
+	switch sign := string(match[7]); sign {
+	case "Z", "z":
+		location = time.UTC
+	case "+", "-":
+		hours, _ := strconv.Atoi(string(match[8]))
+		minutes, _ := strconv.Atoi(string(match[9]))
+		seconds := hours*3600 + minutes*60
+		if sign == "-" {
+			seconds = -seconds
+		}
+		location = time.FixedZone("", seconds)
+	}
+	created, err := time.ParseInLocation("20060102150405", value, location)
+	return created, err == nil
+}
+
+type coreProperties struct {
+	Created string `xml:"created"`
+}
+
+func officeCreationDate(filePath string) (time.Time, bool) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer archive.Close()
+	for _, file := range archive.File {
+		if file.Name != "docProps/core.xml" {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return time.Time{}, false
+		}
+		var properties coreProperties
+		err = xml.NewDecoder(reader).Decode(&properties)
+		reader.Close()
+		if err != nil {
+			return time.Time{}, false
+		}
+		created, err := time.Parse(time.RFC3339, strings.TrimSpace(properties.Created))
+		return created, err == nil
+	}
+	return time.Time{}, false
+}
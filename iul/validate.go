@@ -0,0 +1,260 @@
+package iul
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	// SeverityOff disables a rule, as does a missing severity.
+	SeverityOff Severity = "off"
+)
+
+const (
+	RuleNoFiles            = "no_files"
+	RuleNoWorkbook         = "no_workbook"
+	RuleTemplateControl    = "template_control"
+	RuleRequiredControl    = "required_control"
+	RuleMinAuthors         = "min_authors"
+	RuleEmptyAuthor        = "empty_author"
+	RuleRequiredTitle      = "required_title"
+	RuleFileNameCharacters = "file_name_characters"
+	RuleMaxFileSize        = "max_file_size"
+	RuleFileNaming         = "file_naming"
+	RuleFileNamingControl  = "file_naming_control"
+	RuleDuplicateFile      = "duplicate_file"
+	validationRulesFile    = "validation.json"
+)
+
+// ValidationRules configures the checks run before rendering. Severities maps
+// the rule names above to the severity of their problems.
+type ValidationRules struct {
+	Severities           map[string]Severity `json:"severities"`
+	RequiredControlCells []string            `json:"requiredControlCells,omitempty"`
+	MinAuthors           int                 `json:"minAuthors,omitempty"`
+	RequiredTitles       []string            `json:"requiredTitles,omitempty"`
+	ForbiddenCharacters  string              `json:"forbiddenCharacters,omitempty"`
+	MaxFileSize          int64               `json:"maxFileSize,omitempty"`
+	NamingRules          []NamingRule        `json:"namingRules,omitempty"`
+}
+
+// NamingRule is a regular expression a file name may match, such as
+// ^(?P<code>[^-]+)-(?P<mark>[А-Я]+)-(?P<sheet>\d+)\.pdf$. Control maps named
+// groups to the control sheet cells they must be equal to.
+type NamingRule struct {
+	Pattern string            `json:"pattern"`
+	Control map[string]string `json:"control,omitempty"`
+}
+
+var DefaultValidationRules = ValidationRules{
+	Severities: map[string]Severity{
+		RuleNoFiles:            SeverityError,
+		RuleNoWorkbook:         SeverityWarning,
+		RuleTemplateControl:    SeverityError,
+		RuleRequiredControl:    SeverityError,
+		RuleMinAuthors:         SeverityWarning,
+		RuleEmptyAuthor:        SeverityWarning,
+		RuleRequiredTitle:      SeverityWarning,
+		RuleFileNameCharacters: SeverityWarning,
+		RuleMaxFileSize:        SeverityWarning,
+		RuleFileNaming:         SeverityWarning,
+		RuleFileNamingControl:  SeverityWarning,
+		RuleDuplicateFile:      SeverityWarning,
+	},
+	MinAuthors:          1,
+	RequiredTitles:      DefaultAuthorTitles,
+	ForbiddenCharacters: `<>:"/\|?*`,
+}
+
+// LocalizeTitles returns the rules with the DefaultAuthorTitles among the
+// required titles replaced by titles, the localised titles the authors get.
+func (r ValidationRules) LocalizeTitles(titles []string) ValidationRules {
+	required := slices.Clone(r.RequiredTitles)
+	for i, title := range required {
+		if index := slices.Index(DefaultAuthorTitles, title); index >= 0 && index < len(titles) {
+			required[i] = titles[index]
+		}
+	}
+	r.RequiredTitles = required
+	return r
+}
+
+// Problem is a failed check. Subject names what failed: a file, a control
+// cell, an author title; Value is the offending value, if any, and Cell the
+// control cell it was compared with. File is set for problems of one file.
+type Problem struct {
+	Rule     string
+	Severity Severity
+	Subject  string
+	Value    string
+	Cell     string
+	File     string
+}
+
+// DefaultValidationRulesPath returns the rules location in the user config dir.
+func DefaultValidationRulesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cacheDirName, validationRulesFile), nil
+}
+
+// LoadValidationRules reads the rules stored at rulesPath over the default
+// rules. A missing file gives the default rules.
+func LoadValidationRules(rulesPath string) (ValidationRules, error) {
+	rules := DefaultValidationRules
+	rules.Severities = maps.Clone(DefaultValidationRules.Severities)
+	data, err := os.ReadFile(rulesPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return rules, nil
+	}
+	if err != nil {
+		return rules, err
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return rules, err
+	}
+	for _, rule := range rules.NamingRules {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return rules, err
+		}
+	}
+	return rules, nil
+}
+
+// HasErrors tells whether any of the problems blocks rendering.
+func HasErrors(problems []Problem) bool {
+	for _, problem := range problems {
+		if problem.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// FileProblems groups the problems of single files by file name.
+func FileProblems(problems []Problem) map[string][]Problem {
+	files := make(map[string][]Problem)
+	for _, problem := range problems {
+		if problem.File != "" {
+			files[problem.File] = append(files[problem.File], problem)
+		}
+	}
+	return files
+}
+
+// checkNaming matches the file name against the naming rules and compares the
+// groups of the first matching rule with the control cells. Rules with an
+// invalid pattern are skipped; LoadValidationRules reports them.
+func checkNaming(name string, cells map[string]string, rules []NamingRule) (matched bool, value string, cell string) {
+	for _, rule := range rules {
+		rx, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		match := rx.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		for i, groupName := range rx.SubexpNames() {
+			controlCell, ok := rule.Control[groupName]
+			if ok && groupName != "" && match[i] != strings.TrimSpace(cells[controlCell]) {
+				return true, match[i], controlCell
+			}
+		}
+		return true, "", ""
+	}
+	return false, "", ""
+}
+
+// Validate checks the project against the rules. templateFields are the
+// placeholders of the chosen template; every Control cell they use must have
+// a value.
+func Validate(project *Project, templateFields []string, rules ValidationRules) []Problem {
+	var problems []Problem
+	add := func(problem Problem) {
+		problem.Severity = rules.Severities[problem.Rule]
+		if problem.Severity == SeverityError || problem.Severity == SeverityWarning {
+			problems = append(problems, problem)
+		}
+	}
+	report := func(rule string, subject string, value string) {
+		add(Problem{Rule: rule, Subject: subject, Value: value})
+	}
+	if len(project.Files) == 0 {
+		report(RuleNoFiles, project.Folder, "")
+	}
+	if project.WorkbookPath == "" {
+		report(RuleNoWorkbook, "", "")
+	}
+	cells := project.Control.Cells()
+	reported := make(map[string]bool)
+	for _, cell := range ControlCells(templateFields) {
+		if strings.TrimSpace(cells[cell]) == "" {
+			reported[cell] = true
+			report(RuleTemplateControl, cell, "")
+		}
+	}
+	for _, cell := range rules.RequiredControlCells {
+		if strings.TrimSpace(cells[cell]) == "" && !reported[cell] {
+			reported[cell] = true
+			report(RuleRequiredControl, cell, "")
+		}
+	}
+	if len(project.Authors) < rules.MinAuthors {
+		report(RuleMinAuthors, strconv.Itoa(rules.MinAuthors), "")
+	}
+	titles := make(map[string]bool)
+	for _, author := range project.Authors {
+		if strings.TrimSpace(author.Name) == "" {
+			report(RuleEmptyAuthor, author.Title, "")
+			continue
+		}
+		titles[author.Title] = true
+	}
+	for _, title := range rules.RequiredTitles {
+		if !titles[title] {
+			report(RuleRequiredTitle, title, "")
+		}
+	}
+	duplicates := Duplicates(project.Files)
+	for _, file := range project.Files {
+		if original, ok := duplicates[file.FileName]; ok {
+			add(Problem{Rule: RuleDuplicateFile, Subject: file.FileName, Value: original, File: file.FileName})
+		}
+		name := file.FileName
+		if file.Archive != "" {
+			name = path.Base(name)
+		}
+		if index := strings.IndexAny(name, rules.ForbiddenCharacters); index >= 0 {
+			add(Problem{Rule: RuleFileNameCharacters, Subject: file.FileName, Value: string([]rune(name[index:])[:1]), File: file.FileName})
+		}
+		if rules.MaxFileSize > 0 && file.FileSize > rules.MaxFileSize {
+			add(Problem{Rule: RuleMaxFileSize, Subject: file.FileName, Value: strconv.FormatInt(rules.MaxFileSize, 10), File: file.FileName})
+		}
+		if len(rules.NamingRules) == 0 || file.Archive != "" {
+			continue
+		}
+		matched, value, cell := checkNaming(name, cells, rules.NamingRules)
+		if !matched {
+			add(Problem{Rule: RuleFileNaming, Subject: file.FileName, File: file.FileName})
+		} else if cell != "" {
+			add(Problem{Rule: RuleFileNamingControl, Subject: file.FileName, Value: value, Cell: cell, File: file.FileName})
+		}
+	}
+	return problems
+}
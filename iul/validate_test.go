@@ -0,0 +1,155 @@
+package iul
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func problemKeys(problems []Problem) []string {
+	keys := make([]string, len(problems))
+	for i, problem := range problems {
+		keys[i] = string(problem.Severity) + " " + problem.Rule + " " + problem.Subject
+	}
+	return keys
+}
+
+func TestValidateEmptyProject(t *testing.T) {
+	problems := Validate(&Project{Folder: "folder"}, []string{"Control.F7", "Items_FileName"}, DefaultValidationRules)
+	want := []string{
+		"error no_files folder",
+		"warning no_workbook ",
+		"error template_control F7",
+		"warning min_authors 1",
+		"warning required_title Разраб.",
+		"warning required_title Проверил",
+	}
+	if got := problemKeys(problems); !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %q, want %q", got, want)
+	}
+	if !HasErrors(problems) {
+		t.Error("HasErrors() = false for a project without files")
+	}
+}
+
+func TestValidateProject(t *testing.T) {
+	project := &Project{
+		Files: []CheckedFile{
+			{FileName: "01 ПЗ.pdf", FileSize: 10},
+			{FileName: "big?.pdf", FileSize: 1000},
+			{FileName: "a.zip/inner/b.pdf", Archive: "a.zip", FileSize: 5},
+		},
+		WorkbookPath: "book.xlsx",
+		Control:      ControlSheet{Rows: [][]string{{"Шифр"}}},
+		Authors: []Author{
+			{Title: "Разраб.", Name: "Иванов"},
+			{Title: "Н.контр.", Name: " "},
+		},
+	}
+	rules := DefaultValidationRules
+	rules.RequiredControlCells = []string{"A1", "B1"}
+	rules.MaxFileSize = 100
+	problems := Validate(project, []string{"Control.A1"}, rules)
+	want := []string{
+		"error required_control B1",
+		"warning empty_author Н.контр.",
+		"warning required_title Проверил",
+		"warning file_name_characters big?.pdf",
+		"warning max_file_size big?.pdf",
+	}
+	if got := problemKeys(problems); !reflect.DeepEqual(got, want) {
+		t.Errorf("Validate() = %q, want %q", got, want)
+	}
+	if problems[3].Value != "?" {
+		t.Errorf("forbidden character = %q, want %q", problems[3].Value, "?")
+	}
+
+	rules.Severities = map[string]Severity{RuleRequiredControl: SeverityWarning}
+	problems = Validate(project, nil, rules)
+	if got := problemKeys(problems); !reflect.DeepEqual(got, []string{"warning required_control B1"}) || HasErrors(problems) {
+		t.Errorf("Validate() with one rule = %q", got)
+	}
+}
+
+func TestValidateDefaultTitles(t *testing.T) {
+	project := &Project{}
+	project.SetWorkbookData(ControlSheet{}, []Author{{Name: "Иванов"}, {Name: "Петров"}}, DefaultAuthorTitles)
+	for _, problem := range Validate(project, nil, DefaultValidationRules) {
+		if problem.Rule == RuleRequiredTitle {
+			t.Errorf("Validate() with the default titles = %+v", problem)
+		}
+	}
+
+	titles := []string{"Developer", "Checked"}
+	project.SetWorkbookData(ControlSheet{}, []Author{{Name: "Ivanov"}, {Name: "Petrov"}}, titles)
+	rules := DefaultValidationRules.LocalizeTitles(titles)
+	for _, problem := range Validate(project, nil, rules) {
+		if problem.Rule == RuleRequiredTitle {
+			t.Errorf("Validate() with localised titles = %+v", problem)
+		}
+	}
+	if !reflect.DeepEqual(DefaultValidationRules.RequiredTitles, DefaultAuthorTitles) {
+		t.Errorf("LocalizeTitles() changed the default rules: %v", DefaultValidationRules.RequiredTitles)
+	}
+}
+
+func TestLoadValidationRules(t *testing.T) {
+	dir := t.TempDir()
+	rules, err := LoadValidationRules(filepath.Join(dir, "missing.json"))
+	if err != nil || !reflect.DeepEqual(rules, DefaultValidationRules) {
+		t.Fatalf("LoadValidationRules() of a missing file = %+v, %v", rules, err)
+	}
+	rulesPath := writeFile(t, dir, "validation.json", `{"severities": {"no_workbook": "error", "required_title": "off"}, "requiredControlCells": ["F7"]}`)
+	rules, err = LoadValidationRules(rulesPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.Severities[RuleNoWorkbook] != SeverityError || rules.Severities[RuleRequiredTitle] != SeverityOff || rules.Severities[RuleNoFiles] != SeverityError {
+		t.Errorf("severities = %v", rules.Severities)
+	}
+	if !reflect.DeepEqual(rules.RequiredControlCells, []string{"F7"}) || rules.MinAuthors != 1 {
+		t.Errorf("rules = %+v", rules)
+	}
+	if DefaultValidationRules.Severities[RuleNoWorkbook] != SeverityWarning {
+		t.Error("LoadValidationRules() changed the default rules")
+	}
+	invalidPath := writeFile(t, dir, "invalid.json", `{"namingRules": [{"pattern": "(unclosed"}]}`)
+	if _, err := LoadValidationRules(invalidPath); err == nil {
+		t.Error("LoadValidationRules() accepted an invalid naming pattern")
+	}
+}
+
+func TestValidateNaming(t *testing.T) {
+	project := &Project{
+		Files: []CheckedFile{
+			{FileName: "123-2024-АР-1.pdf"},
+			{FileName: "999-2024-АР-2.pdf"},
+			{FileName: "Пояснительная записка.pdf"},
+			{FileName: "a.zip"},
+			{FileName: "a.zip/anything.txt", Archive: "a.zip"},
+		},
+		Control: ControlSheet{Rows: [][]string{{"", "123-2024"}}},
+	}
+	rules := ValidationRules{
+		Severities: map[string]Severity{RuleFileNaming: SeverityWarning, RuleFileNamingControl: SeverityError},
+		NamingRules: []NamingRule{
+			{Pattern: `^(?P<code>\d+-\d+)-(?P<mark>[А-Я]+)-(?P<sheet>\d+)\.pdf$`, Control: map[string]string{"code": "B1"}},
+			{Pattern: `\.zip$`},
+		},
+	}
+	problems := Validate(project, nil, rules)
+	want := []string{
+		"error file_naming_control 999-2024-АР-2.pdf",
+		"warning file_naming Пояснительная записка.pdf",
+	}
+	if got := problemKeys(problems); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Validate() = %q, want %q", got, want)
+	}
+	if problems[0].Value != "999-2024" || problems[0].Cell != "B1" {
+		t.Errorf("naming control problem = %+v", problems[0])
+	}
+	files := FileProblems(problems)
+	if len(files) != 2 || len(files["999-2024-АР-2.pdf"]) != 1 {
+		t.Errorf("FileProblems() = %v", files)
+	}
+}
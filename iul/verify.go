@@ -0,0 +1,83 @@
+package iul
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+)
+
+// Mismatch describes a file whose current state differs from the recorded one.
+type Mismatch struct {
+	FileName string
+	Expected CheckedFile
+	Actual   CheckedFile
+	Missing  bool
+}
+
+// Verify recomputes the checksum and size of every recorded file in dir and
+// reports the files that are missing or differ. Archive entries are compared
+// with the current contents of their archive.
+func Verify(ctx context.Context, dir string, files []CheckedFile) ([]Mismatch, error) {
+	var mismatches []Mismatch
+	archives := make(map[string]map[string]CheckedFile)
+	for _, expected := range files {
+		var actual CheckedFile
+		var err error
+		if expected.Archive != "" {
+			actual, err = verifiedArchiveEntry(ctx, dir, expected, archives)
+		} else {
+			actual, err = ChecksumFile(ctx, dir, expected.FileName, TimestampOptions{Source: TimestampModified})
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			mismatches = append(mismatches, Mismatch{FileName: expected.FileName, Expected: expected, Missing: true})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if actual.Checksum != expected.Checksum || actual.FileSize != expected.FileSize {
+			mismatches = append(mismatches, Mismatch{FileName: expected.FileName, Expected: expected, Actual: actual})
+		}
+	}
+	return mismatches, nil
+}
+
+// verifiedArchiveEntry looks the entry up in its archive, reading every
+// archive once.
+func verifiedArchiveEntry(ctx context.Context, dir string, expected CheckedFile, archives map[string]map[string]CheckedFile) (CheckedFile, error) {
+	entries, ok := archives[expected.Archive]
+	if !ok {
+		archiveEntries, err := ReadArchive(ctx, dir, expected.Archive, TimestampOptions{Source: TimestampModified})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return CheckedFile{}, err
+		}
+		entries = make(map[string]CheckedFile, len(archiveEntries))
+		for _, entry := range archiveEntries {
+			entries[entry.FileName] = entry
+		}
+		archives[expected.Archive] = entries
+	}
+	actual, ok := entries[expected.FileName]
+	if !ok {
+		return CheckedFile{}, fs.ErrNotExist
+	}
+	return actual, nil
+}
+
+// VerifyManifest checks the scanned files and the workbook recorded in the
+// manifest against their current contents.
+func VerifyManifest(ctx context.Context, manifest *Manifest) ([]Mismatch, error) {
+	mismatches, err := Verify(ctx, manifest.Folder, manifest.Files)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Workbook != nil && manifest.WorkbookPath != "" {
+		workbookMismatches, err := Verify(ctx, filepath.Dir(manifest.WorkbookPath), []CheckedFile{*manifest.Workbook})
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, workbookMismatches...)
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,60 @@
+package iul
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// WatchDelay is how long Watch waits for a folder to settle before reporting
+// changes, so that a file written in several chunks is only rehashed once.
+const WatchDelay = 500 * time.Millisecond
+
+// Watch reports the names of the files of dir that were created, written,
+// renamed or removed, in batches of changes separated by WatchDelay. It blocks
+// until ctx is cancelled or the watcher fails.
+func Watch(ctx context.Context, dir string, onChange func(names []string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := watcher.Add(dir); err != nil {
+		return err
+	}
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(WatchDelay)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			pending[filepath.Base(event.Name)] = true
+			timer.Reset(WatchDelay)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case <-timer.C:
+			names := make([]string, 0, len(pending))
+			for name := range pending {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			clear(pending)
+			onChange(names)
+		}
+	}
+}
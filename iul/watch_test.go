@@ -0,0 +1,112 @@
+package iul
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRescanAndUpdateFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.pdf", "a")
+	writeFile(t, dir, "b.pdf", "b")
+	writeFile(t, dir, "c.pdf", "c")
+	files, err := Scan(context.Background(), dir, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := &Project{Folder: dir, Files: files}
+	project.MoveFile(2, 0)
+	project.SetBaseline()
+
+	writeFile(t, dir, "b.pdf", "changed")
+	writeFile(t, dir, "d.pdf", "d")
+	if err := os.Remove(filepath.Join(dir, "a.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	changed, removed, err := Rescan(context.Background(), dir, []string{"a.pdf", "b.pdf", "d.pdf"}, ScanOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(removed, []string{"a.pdf"}) || len(changed) != 2 {
+		t.Fatalf("Rescan() = %v changed, %v removed", changed, removed)
+	}
+	project.UpdateFiles(changed, removed)
+
+	wantOrder := []string{"c.pdf", "b.pdf", "d.pdf"}
+	wantStatus := []FileStatus{StatusUnchanged, StatusChanged, StatusNew}
+	for i, file := range project.Files {
+		if file.FileName != wantOrder[i] {
+			t.Errorf("Files[%d] = %s, want %s", i, file.FileName, wantOrder[i])
+		}
+		if status := project.FileStatus(file); status != wantStatus[i] {
+			t.Errorf("FileStatus(%s) = %s, want %s", file.FileName, status, wantStatus[i])
+		}
+	}
+}
+
+func TestStaleFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.pdf", "a")
+	writeFile(t, dir, "b.pdf", "b")
+	output := writeFile(t, dir, "result.docx", "output")
+	options := ScanOptions{Exclude: []string{output}}
+	files, err := Scan(context.Background(), dir, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stale, err := StaleFiles(dir, files, options); err != nil || len(stale) != 0 {
+		t.Fatalf("StaleFiles() = %v, %v right after a scan", stale, err)
+	}
+
+	writeFile(t, dir, "result.docx", "rendered again")
+	writeFile(t, dir, "a.pdf", "re-exported")
+	writeFile(t, dir, "c.pdf", "c")
+	if err := os.Remove(filepath.Join(dir, "b.pdf")); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := StaleFiles(dir, files, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.pdf", "c.pdf", "b.pdf"}; !reflect.DeepEqual(stale, want) {
+		t.Errorf("StaleFiles() = %v, want %v", stale, want)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan []string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, dir, func(names []string) {
+			changes <- names
+		})
+	}()
+	// Give the watcher time to register the folder.
+	time.Sleep(100 * time.Millisecond)
+
+	writeFile(t, dir, "b.pdf", "b")
+	writeFile(t, dir, "a.pdf", "a")
+	writeFile(t, dir, "a.pdf", "aa")
+	select {
+	case names := <-changes:
+		if want := []string{"a.pdf", "b.pdf"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("Watch() reported %v, want %v", names, want)
+		}
+	case err := <-done:
+		t.Fatalf("Watch() stopped early: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() reported no changes")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch() = %v after cancel", err)
+	}
+}
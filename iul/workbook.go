@@ -0,0 +1,114 @@
+package iul
+
+import (
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkbookExtensions are the control workbook formats: Office Open XML,
+// Excel 97-2003 (BIFF8) and OpenDocument spreadsheets.
+var WorkbookExtensions = []string{".xlsx", ".xlsm", ".xls", ".ods"}
+
+// WorkbookOptions control how the cell values are read.
+type WorkbookOptions struct {
+	// RawValues reads the stored values instead of the values formatted with
+	// the cell number format.
+	RawValues bool
+	// Recalculate evaluates every formula instead of using the values cached
+	// by the spreadsheet application, which may be stale. Only XLSX formulas
+	// are evaluated; XLS and ODS cells keep their cached values.
+	Recalculate bool
+}
+
+// Workbook is an open spreadsheet file.
+type Workbook interface {
+	// Sheet reads the named sheet; a missing sheet is an error.
+	Sheet(name string, options WorkbookOptions) (ControlSheet, error)
+	Close() error
+}
+
+// OpenWorkbook opens the workbook at path by its extension.
+func OpenWorkbook(path string) (Workbook, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xls":
+		return openXLS(path)
+	case ".ods":
+		return openODS(path)
+	case ".xlsx", ".xlsm":
+		file, err := excelize.OpenFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return xlsxWorkbook{file: file}, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported workbook format, want one of %s", path, strings.Join(WorkbookExtensions, ", "))
+}
+
+// builtinNumberFormats are the built-in number formats the XLS reader applies
+// besides the dates.
+var builtinNumberFormats = map[int]string{
+	1:  "0",
+	2:  "0.00",
+	3:  "#,##0",
+	4:  "#,##0.00",
+	9:  "0%",
+	10: "0.00%",
+}
+
+// formatNumber formats a cell number the way the spreadsheet displays it, as
+// far as the decimals, percents and dates of its number format go. Grouping,
+// currencies and other sections are left out.
+func formatNumber(value float64, format string, date1904 bool) string {
+	if isDateFormat(0, &format) {
+		if date, err := excelize.ExcelDateToTime(value, date1904); err == nil {
+			if date.Hour() != 0 || date.Minute() != 0 || date.Second() != 0 {
+				return date.Format("02.01.2006 15:04:05")
+			}
+			return date.Format(DefaultCellDateLayout)
+		}
+	}
+	section, _, _ := strings.Cut(rxNumberFormatLiteral.ReplaceAllString(format, ""), ";")
+	percent := strings.Contains(section, "%")
+	if percent {
+		value *= 100
+	}
+	var text string
+	if _, fraction, ok := strings.Cut(section, "."); ok {
+		decimals := len(fraction) - len(strings.TrimLeft(fraction, "0#"))
+		text = strconv.FormatFloat(value, 'f', decimals, 64)
+	} else if strings.ContainsAny(section, "0#") {
+		text = strconv.FormatFloat(math.Round(value), 'f', 0, 64)
+	} else {
+		text = formatGeneral(value)
+	}
+	if percent {
+		text += "%"
+	}
+	return text
+}
+
+// formatGeneral formats a number with the 15 significant digits spreadsheets
+// keep, dropping the binary noise of calculated values.
+func formatGeneral(value float64) string {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(value, 'g', 15, 64), 64)
+	if err != nil {
+		rounded = value
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// parseDate reads an ISO 8601 date or date and time without a zone as UTC,
+// the way excelize returns workbook dates.
+func parseDate(value string) (time.Time, bool) {
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", time.DateOnly} {
+		if date, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
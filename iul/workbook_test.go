@@ -0,0 +1,390 @@
+package iul
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+const testODSContent = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" office:version="1.2">
+<office:body><office:spreadsheet>
+<table:table table:name="Лист управления">
+<table:table-column table:number-columns-repeated="1024"/>
+<table:table-row>
+<table:table-cell table:number-columns-spanned="2" table:number-rows-spanned="1" office:value-type="string"><text:p>Шифр</text:p></table:table-cell>
+<table:covered-table-cell/>
+<table:table-cell office:value-type="date" office:date-value="2024-03-05"><text:p>05.03.24</text:p></table:table-cell>
+<table:table-cell office:value-type="percentage" office:value="0.25"><text:p>25%</text:p></table:table-cell>
+<table:table-cell table:number-columns-repeated="1020"/>
+</table:table-row>
+<table:table-row table:number-rows-repeated="3"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+<table:table-row>
+<table:table-cell/>
+<table:table-cell office:value-type="string"><text:p>123-<text:span>ИУЛ</text:span></text:p><text:p>a<text:s text:c="2"/>b</text:p><office:annotation><text:p>комментарий</text:p></office:annotation></table:table-cell>
+<table:table-cell table:formula="of:=[.D1]*2" office:value-type="float" office:value="0.5"><text:p>0,5</text:p></table:table-cell>
+</table:table-row>
+<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+</table:table>
+<table:table table:name="Содержание">
+<table:table-row table:number-rows-repeated="4"><table:table-cell/></table:table-row>
+<table:table-row><table:table-cell table:number-columns-repeated="3"/><table:table-cell office:value-type="string"><text:p>ГИП</text:p></table:table-cell><table:table-cell office:value-type="string"><text:p>Иванов</text:p></table:table-cell></table:table-row>
+</table:table>
+</office:spreadsheet></office:body>
+</office:document-content>`
+
+func writeODS(t *testing.T, content string) string {
+	t.Helper()
+	workbookPath := filepath.Join(t.TempDir(), "book.ods")
+	file, err := os.Create(workbookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	for name, data := range map[string]string{"mimetype": "application/vnd.oasis.opendocument.spreadsheet", odsContentFile: content} {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return workbookPath
+}
+
+func TestReadODSWorkbook(t *testing.T) {
+	workbookPath := writeODS(t, testODSContent)
+	control, authors, err := ReadWorkbook(context.Background(), workbookPath, WorkbookOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRows := [][]string{
+		{"Шифр", "", "05.03.24", "25%"},
+		nil, nil, nil,
+		{"", "123-ИУЛ\na  b", "0,5"},
+	}
+	if !reflect.DeepEqual(control.Rows, wantRows) {
+		t.Errorf("rows = %q, want %q", control.Rows, wantRows)
+	}
+	if want := []MergedCell{{StartRow: 0, StartCol: 0, EndRow: 0, EndCol: 1}}; !reflect.DeepEqual(control.Merged, want) {
+		t.Errorf("merged = %+v, want %+v", control.Merged, want)
+	}
+	if want := map[string]time.Time{"C1": time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)}; !reflect.DeepEqual(control.Dates, want) {
+		t.Errorf("dates = %v, want %v", control.Dates, want)
+	}
+	if len(authors) == 0 || authors[0] != (Author{Title: "ГИП", Name: "Иванов"}) {
+		t.Errorf("authors = %v, want ГИП Иванов first", authors)
+	}
+
+	control, _, err = ReadWorkbook(context.Background(), workbookPath, WorkbookOptions{RawValues: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := control.Cells()
+	if cells["C1"] != "2024-03-05" || cells["D1"] != "0.25" || cells["C5"] != "0.5" || cells["A1"] != "Шифр" {
+		t.Errorf("raw A1, C1, D1, C5 = %q, %q, %q, %q", cells["A1"], cells["C1"], cells["D1"], cells["C5"])
+	}
+}
+
+// biffRecords encodes BIFF records, splitting data longer than the record
+// limit into CONTINUE records at the given offsets.
+func biffRecords(id uint16, data []byte, continues ...int) []byte {
+	var stream []byte
+	starts := append([]int{0}, continues...)
+	for i, start := range starts {
+		end := len(data)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		recordID := id
+		if i > 0 {
+			recordID = biffContinue
+		}
+		stream = binary.LittleEndian.AppendUint16(stream, recordID)
+		stream = binary.LittleEndian.AppendUint16(stream, uint16(end-start))
+		stream = append(stream, data[start:end]...)
+	}
+	return stream
+}
+
+func biffCell(row, col, xf uint16, data ...[]byte) []byte {
+	cell := binary.LittleEndian.AppendUint16(nil, row)
+	cell = binary.LittleEndian.AppendUint16(cell, col)
+	cell = binary.LittleEndian.AppendUint16(cell, xf)
+	for _, part := range data {
+		cell = append(cell, part...)
+	}
+	return cell
+}
+
+func biffUnicode(value string, long bool) []byte {
+	units := utf16.Encode([]rune(value))
+	var data []byte
+	if long {
+		data = binary.LittleEndian.AppendUint16(data, uint16(len(units)))
+	} else {
+		data = append(data, byte(len(units)))
+	}
+	data = append(data, 1)
+	for _, unit := range units {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+	return data
+}
+
+func biffFloat(value float64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, math.Float64bits(value))
+}
+
+// writeXLS stores a workbook stream in a compound file of 512 byte sectors:
+// the FAT, the directory and the stream, padded out of the mini stream.
+func writeXLS(t testing.TB, stream []byte) string {
+	t.Helper()
+	le := binary.LittleEndian
+	const sectorSize = 512
+	stream = append(stream, make([]byte, max(4096-len(stream), 0))...)
+	streamSectors := (len(stream) + sectorSize - 1) / sectorSize
+	data := make([]byte, sectorSize*(3+streamSectors))
+	copy(data, cfbSignature)
+	le.PutUint16(data[0x18:], 0x3E)
+	le.PutUint16(data[0x1A:], 3)
+	le.PutUint16(data[0x1C:], 0xFFFE)
+	le.PutUint16(data[0x1E:], 9)
+	le.PutUint16(data[0x20:], 6)
+	le.PutUint32(data[0x2C:], 1)
+	le.PutUint32(data[0x30:], 1)
+	le.PutUint32(data[0x38:], 4096)
+	le.PutUint32(data[0x3C:], cfbEndOfChain)
+	le.PutUint32(data[0x44:], cfbEndOfChain)
+	for i := range cfbHeaderDIFATLen {
+		le.PutUint32(data[0x4C+4*i:], math.MaxUint32)
+	}
+	le.PutUint32(data[0x4C:], 0)
+	fat := data[sectorSize : 2*sectorSize]
+	for i := 0; i < sectorSize; i += 4 {
+		le.PutUint32(fat[i:], math.MaxUint32)
+	}
+	le.PutUint32(fat[0:], 0xFFFFFFFD)
+	le.PutUint32(fat[4:], cfbEndOfChain)
+	for i := range streamSectors {
+		next := uint32(3 + i)
+		if i == streamSectors-1 {
+			next = cfbEndOfChain
+		}
+		le.PutUint32(fat[4*(2+i):], next)
+	}
+	directory := data[2*sectorSize : 3*sectorSize]
+	entry := func(offset int, name string, kind byte, start uint32, size int) {
+		units := utf16.Encode([]rune(name))
+		for i, unit := range units {
+			le.PutUint16(directory[offset+2*i:], unit)
+		}
+		le.PutUint16(directory[offset+64:], uint16(2*len(units)+2))
+		directory[offset+66] = kind
+		le.PutUint32(directory[offset+116:], start)
+		le.PutUint32(directory[offset+120:], uint32(size))
+	}
+	entry(0, "Root Entry", 5, cfbEndOfChain, 0)
+	entry(cfbDirEntrySize, "Workbook", 2, 2, len(stream))
+	copy(data[3*sectorSize:], stream)
+	workbookPath := filepath.Join(t.TempDir(), "book.xls")
+	if err := os.WriteFile(workbookPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return workbookPath
+}
+
+func TestReadXLSWorkbook(t *testing.T) {
+	le := binary.LittleEndian
+	bof := func(kind uint16) []byte {
+		return biffRecords(biffBOF, append(le.AppendUint16(le.AppendUint16(nil, biffVersion8), kind), make([]byte, 12)...))
+	}
+	eof := biffRecords(biffEOF, nil)
+	xf := func(format uint16) []byte {
+		return biffRecords(biffXF, append(le.AppendUint16(le.AppendUint16(nil, 0), format), make([]byte, 16)...))
+	}
+	boundSheet := func(offset uint32, name string) []byte {
+		return biffRecords(biffBoundSheet, append(le.AppendUint32(nil, offset), append([]byte{0, 0}, biffUnicode(name, false)...)...))
+	}
+	// The second shared string is split over a CONTINUE record, going on
+	// compressed after a new flags byte.
+	sst := le.AppendUint32(le.AppendUint32(nil, 3), 3)
+	sst = append(sst, biffUnicode("Шифр", true)...)
+	split := len(sst) + 3 + 2*4
+	sst = append(sst, le.AppendUint16(nil, 7)...)
+	sst = append(sst, 1, '1', 0, '2', 0, '3', 0, '-', 0)
+	sst = append(sst, 0, 'A', 'B', 'C')
+	sst = append(sst, biffUnicode("Иванов", true)...)
+	globals := func(controlOffset, authorOffset uint32) []byte {
+		stream := bof(0x0005)
+		stream = append(stream, biffRecords(biffFormat, append(le.AppendUint16(nil, 164), biffUnicode("dd.mm.yyyy", true)...))...)
+		stream = append(stream, xf(0)...)
+		stream = append(stream, xf(164)...)
+		stream = append(stream, xf(9)...)
+		stream = append(stream, xf(2)...)
+		stream = append(stream, boundSheet(controlOffset, CONTROL_SHEET_NAME)...)
+		stream = append(stream, boundSheet(authorOffset, AUTHOR_SHEET_NAME)...)
+		stream = append(stream, biffRecords(biffSST, sst, split)...)
+		return append(stream, eof...)
+	}
+	control := bof(0x0010)
+	control = append(control, biffRecords(biffLabelSST, biffCell(0, 0, 0, le.AppendUint32(nil, 0)))...)
+	control = append(control, biffRecords(biffNumber, biffCell(0, 2, 1, biffFloat(45356)))...)
+	control = append(control, biffRecords(biffRK, biffCell(0, 3, 2, le.AppendUint32(nil, 0x3FD00000)))...)
+	control = append(control, biffRecords(biffMulRK, append(biffCell(1, 0, 3, le.AppendUint32(nil, 6<<2|0x02)), append(le.AppendUint16(nil, 0), append(le.AppendUint32(nil, 150<<2|0x03), le.AppendUint16(nil, 1)...)...)...))...)
+	control = append(control, biffRecords(biffLabelSST, biffCell(4, 1, 0, le.AppendUint32(nil, 1)))...)
+	control = append(control, biffRecords(biffFormula, biffCell(4, 2, 0, biffFloat(0.1+0.2), make([]byte, 6)))...)
+	stringResult := []byte{0, 0, 0, 0, 0, 0, 0xFF, 0xFF}
+	control = append(control, biffRecords(biffFormula, biffCell(4, 3, 0, stringResult, make([]byte, 6)))...)
+	control = append(control, biffRecords(biffString, biffUnicode("ИУЛ", true))...)
+	// An embedded chart substream, whose EOF does not end the sheet.
+	control = append(control, bof(0x0020)...)
+	control = append(control, biffRecords(biffNumber, biffCell(9, 9, 0, biffFloat(1)))...)
+	control = append(control, eof...)
+	control = append(control, biffRecords(biffMergeCells, append(le.AppendUint16(nil, 1), le.AppendUint16(le.AppendUint16(le.AppendUint16(le.AppendUint16(nil, 0), 0), 0), 1)...))...)
+	control = append(control, eof...)
+	authors := bof(0x0010)
+	authors = append(authors, biffRecords(biffLabel, biffCell(4, 3, 0, biffUnicode("ГИП", true)))...)
+	authors = append(authors, biffRecords(biffLabelSST, biffCell(4, 4, 0, le.AppendUint32(nil, 2)))...)
+	authors = append(authors, eof...)
+	controlOffset := uint32(len(globals(0, 0)))
+	stream := append(globals(controlOffset, controlOffset+uint32(len(control))), control...)
+	workbookPath := writeXLS(t, append(stream, authors...))
+
+	got, gotAuthors, err := ReadWorkbook(context.Background(), workbookPath, WorkbookOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRows := [][]string{
+		{"Шифр", "", "05.03.2024", "25%"},
+		{"6.00", "1.5"},
+		nil, nil,
+		{"", "123-ABC", "0.3", "ИУЛ"},
+	}
+	if !reflect.DeepEqual(got.Rows, wantRows) {
+		t.Errorf("rows = %q, want %q", got.Rows, wantRows)
+	}
+	if want := []MergedCell{{StartRow: 0, StartCol: 0, EndRow: 0, EndCol: 1}}; !reflect.DeepEqual(got.Merged, want) {
+		t.Errorf("merged = %+v, want %+v", got.Merged, want)
+	}
+	if want := map[string]time.Time{"C1": time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)}; !reflect.DeepEqual(got.Dates, want) {
+		t.Errorf("dates = %v, want %v", got.Dates, want)
+	}
+	if len(gotAuthors) == 0 || gotAuthors[0] != (Author{Title: "ГИП", Name: "Иванов"}) {
+		t.Errorf("authors = %v, want ГИП Иванов first", gotAuthors)
+	}
+
+	got, _, err = ReadWorkbook(context.Background(), workbookPath, WorkbookOptions{RawValues: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cells := got.Cells(); cells["C1"] != "45356" || cells["D1"] != "0.25" || cells["A2"] != "6" {
+		t.Errorf("raw C1, D1, A2 = %q, %q, %q, want 45356, 0.25, 6", cells["C1"], cells["D1"], cells["A2"])
+	}
+}
+
+func TestReadCFBStreamDIFATCycle(t *testing.T) {
+	le := binary.LittleEndian
+	data, err := os.ReadFile(writeXLS(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The DIFAT sector 2 links back to itself.
+	le.PutUint32(data[0x44:], 2)
+	le.PutUint32(data[0x48:], math.MaxUint32)
+	le.PutUint32(data[4*512-4:], 2)
+	if _, err := readCFBStream(data, "Workbook"); !errors.Is(err, errXLSFormat) {
+		t.Errorf("readCFBStream() error = %v, want %v", err, errXLSFormat)
+	}
+}
+
+// Sector ids from 0x80000000 up are negative as int on 32-bit targets and
+// must be rejected before they are used as offsets.
+func TestReadCFBStreamLargeSectorIDs(t *testing.T) {
+	le := binary.LittleEndian
+	valid, err := os.ReadFile(writeXLS(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, offset := range map[string]int{
+		"fat sector":     0x4C,
+		"directory":      0x30,
+		"mini fat":       0x3C,
+		"workbook start": 2*512 + cfbDirEntrySize + 116,
+		"first fat link": 512 + 8,
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := slices.Clone(valid)
+			le.PutUint32(data[offset:], 0x80000000)
+			if name == "mini fat" {
+				// Keep the workbook in the mini stream to read the mini FAT.
+				le.PutUint32(data[2*512+cfbDirEntrySize+120:], 64)
+			}
+			if _, err := readCFBStream(data, "Workbook"); !errors.Is(err, errXLSFormat) {
+				t.Errorf("readCFBStream() error = %v, want %v", err, errXLSFormat)
+			}
+		})
+	}
+}
+
+func FuzzReadCFBStream(f *testing.F) {
+	data, err := os.ReadFile(writeXLS(f, nil))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = readCFBStream(data, "Workbook")
+	})
+}
+
+func TestOpenWorkbook(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenWorkbook(filepath.Join(dir, "book.csv")); err == nil {
+		t.Error("OpenWorkbook(csv) returned no error")
+	}
+	notXLS := filepath.Join(dir, "book.xls")
+	if err := os.WriteFile(notXLS, []byte("not a workbook"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenWorkbook(notXLS); err == nil {
+		t.Error("OpenWorkbook(invalid xls) returned no error")
+	}
+	workbook, err := OpenWorkbook(writeODS(t, testODSContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer workbook.Close()
+	if _, err := workbook.Sheet("Нет", WorkbookOptions{}); err == nil {
+		t.Error("Sheet(missing) returned no error")
+	}
+}
+
+func TestSearchWorkbook(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a/project.ods", "project.txt", "other.xls"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := SearchWorkbook(dir, "project"), filepath.Join(dir, "a", "project.ods"); got != want {
+		t.Errorf("SearchWorkbook() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,534 @@
+package iul
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/xuri/excelize/v2"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	cfbMaxSector      = 0xFFFFFFFA
+	cfbEndOfChain     = 0xFFFFFFFE
+	cfbDirEntrySize   = 128
+	cfbHeaderDIFATLen = 109
+	biffBOF           = 0x0809
+	biffEOF           = 0x000A
+	biffContinue      = 0x003C
+	biffDateMode      = 0x0022
+	biffFormat        = 0x041E
+	biffXF            = 0x00E0
+	biffBoundSheet    = 0x0085
+	biffSST           = 0x00FC
+	biffLabelSST      = 0x00FD
+	biffLabel         = 0x0204
+	biffNumber        = 0x0203
+	biffRK            = 0x027E
+	biffMulRK         = 0x00BD
+	biffBoolErr       = 0x0205
+	biffFormula       = 0x0006
+	biffString        = 0x0207
+	biffMergeCells    = 0x00E5
+	biffVersion8      = 0x0600
+)
+
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+var errXLSFormat = errors.New("not an Excel 97-2003 workbook")
+
+// xlsWorkbook reads Excel 97-2003 (BIFF8) workbooks. XLS files store numbers
+// without their displayed text, so displayed values are formatted with
+// formatNumber.
+type xlsWorkbook struct {
+	stream   []byte
+	sheets   map[string]uint32
+	sst      []string
+	formats  map[int]string
+	xfs      []int
+	date1904 bool
+}
+
+func openXLS(path string) (*xlsWorkbook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := readCFBStream(data, "Workbook")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	workbook := &xlsWorkbook{stream: stream, sheets: make(map[string]uint32), formats: make(map[int]string)}
+	if err := workbook.readGlobals(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return workbook, nil
+}
+
+func (w *xlsWorkbook) Close() error {
+	return nil
+}
+
+// readCFBStream returns the named stream of a compound file, the container of
+// the Office 97-2003 documents.
+func readCFBStream(data []byte, name string) ([]byte, error) {
+	if len(data) < 512 || !bytes.Equal(data[:8], cfbSignature) {
+		return nil, errXLSFormat
+	}
+	le := binary.LittleEndian
+	sectorSize := 1 << le.Uint16(data[0x1E:])
+	miniSectorSize := 1 << le.Uint16(data[0x20:])
+	if sectorSize != 512 && sectorSize != 4096 || miniSectorSize != 64 {
+		return nil, errXLSFormat
+	}
+	// Sector ids are checked as int64 before they are converted, since a
+	// large id overflows int on 32-bit targets.
+	sector := func(id uint32) []byte {
+		if id > cfbMaxSector || (int64(id)+2)*int64(sectorSize) > int64(len(data)) {
+			return nil
+		}
+		start := (int(id) + 1) * sectorSize
+		return data[start : start+sectorSize]
+	}
+	// The DIFAT lists the FAT sectors, the first 109 in the header.
+	var fatSectors []uint32
+	for i := range cfbHeaderDIFATLen {
+		fatSectors = append(fatSectors, le.Uint32(data[0x4C+4*i:]))
+	}
+	next := le.Uint32(data[0x44:])
+	visited := make(map[uint32]bool)
+	for count := le.Uint32(data[0x48:]); count > 0 && next <= cfbMaxSector; count-- {
+		difat := sector(next)
+		if difat == nil || visited[next] {
+			return nil, errXLSFormat
+		}
+		visited[next] = true
+		for i := 0; i < sectorSize/4-1; i++ {
+			fatSectors = append(fatSectors, le.Uint32(difat[4*i:]))
+		}
+		next = le.Uint32(difat[sectorSize-4:])
+	}
+	var fat []uint32
+	for _, id := range fatSectors[:min(int64(le.Uint32(data[0x2C:])), int64(len(fatSectors)))] {
+		fatSector := sector(id)
+		if fatSector == nil {
+			return nil, errXLSFormat
+		}
+		for i := 0; i < sectorSize; i += 4 {
+			fat = append(fat, le.Uint32(fatSector[i:]))
+		}
+	}
+	chain := func(table []uint32, start uint32, read func(id uint32) []byte) ([]byte, error) {
+		var stream []byte
+		for id, count := start, 0; id != cfbEndOfChain; id, count = table[id], count+1 {
+			if int64(id) >= int64(len(table)) || count > len(table) {
+				return nil, errXLSFormat
+			}
+			block := read(id)
+			if block == nil {
+				return nil, errXLSFormat
+			}
+			stream = append(stream, block...)
+		}
+		return stream, nil
+	}
+	directory, err := chain(fat, le.Uint32(data[0x30:]), sector)
+	if err != nil {
+		return nil, err
+	}
+	var root, entry []byte
+	for i := 0; i+cfbDirEntrySize <= len(directory); i += cfbDirEntrySize {
+		dirEntry := directory[i : i+cfbDirEntrySize]
+		nameLen := min(int(le.Uint16(dirEntry[64:])), 64)
+		entryName := make([]uint16, max(nameLen/2-1, 0))
+		for j := range entryName {
+			entryName[j] = le.Uint16(dirEntry[2*j:])
+		}
+		switch {
+		case dirEntry[66] == 5:
+			root = dirEntry
+		case dirEntry[66] == 2 && string(utf16.Decode(entryName)) == name:
+			entry = dirEntry
+		}
+	}
+	if root == nil || entry == nil {
+		return nil, errXLSFormat
+	}
+	size := int64(le.Uint32(entry[120:]))
+	start := le.Uint32(entry[116:])
+	var stream []byte
+	if size < int64(le.Uint32(data[0x38:])) {
+		// Small streams are kept in the mini stream of the root entry.
+		miniFAT, err := chain(fat, le.Uint32(data[0x3C:]), sector)
+		if err != nil {
+			return nil, err
+		}
+		miniTable := make([]uint32, len(miniFAT)/4)
+		for i := range miniTable {
+			miniTable[i] = le.Uint32(miniFAT[4*i:])
+		}
+		miniStream, err := chain(fat, le.Uint32(root[116:]), sector)
+		if err != nil {
+			return nil, err
+		}
+		stream, err = chain(miniTable, start, func(id uint32) []byte {
+			if (int64(id)+1)*int64(miniSectorSize) > int64(len(miniStream)) {
+				return nil
+			}
+			offset := int(id) * miniSectorSize
+			return miniStream[offset : offset+miniSectorSize]
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		stream, err = chain(fat, start, sector)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if int64(len(stream)) < size {
+		return nil, errXLSFormat
+	}
+	return stream[:size], nil
+}
+
+// biffRecord is a record of the workbook stream with the data of the
+// CONTINUE records following it. continues are the offsets in data where
+// each CONTINUE record starts.
+type biffRecord struct {
+	id        uint16
+	data      []byte
+	continues []int
+}
+
+// readRecords calls read with the records of the substream at offset up to
+// its EOF record. The substreams of embedded charts are skipped.
+func (w *xlsWorkbook) readRecords(offset int, read func(record biffRecord) error) error {
+	le := binary.LittleEndian
+	var record *biffRecord
+	depth := 0
+	for offset+4 <= len(w.stream) {
+		id := le.Uint16(w.stream[offset:])
+		size := int(le.Uint16(w.stream[offset+2:]))
+		offset += 4
+		if offset+size > len(w.stream) {
+			return errXLSFormat
+		}
+		data := w.stream[offset : offset+size]
+		offset += size
+		if id == biffContinue {
+			if record != nil {
+				record.continues = append(record.continues, len(record.data))
+				record.data = append(record.data, data...)
+			}
+			continue
+		}
+		if record != nil {
+			if err := read(*record); err != nil {
+				return err
+			}
+			record = nil
+		}
+		switch id {
+		case biffBOF:
+			depth++
+		case biffEOF:
+			depth--
+			if depth == 0 {
+				return nil
+			}
+			continue
+		}
+		if depth == 1 {
+			record = &biffRecord{id: id, data: slices.Clone(data)}
+		}
+	}
+	return errXLSFormat
+}
+
+func (w *xlsWorkbook) readGlobals() error {
+	le := binary.LittleEndian
+	return w.readRecords(0, func(record biffRecord) error {
+		data := record.data
+		switch {
+		case record.id == biffBOF && len(data) >= 2 && le.Uint16(data) != biffVersion8:
+			return fmt.Errorf("%w: only BIFF8 workbooks are supported", errXLSFormat)
+		case record.id == biffDateMode && len(data) >= 2:
+			w.date1904 = le.Uint16(data) == 1
+		case record.id == biffFormat && len(data) >= 4:
+			format, _ := readBIFFString(&biffReader{record: record, offset: 2}, true)
+			w.formats[int(le.Uint16(data))] = format
+		case record.id == biffXF && len(data) >= 4:
+			w.xfs = append(w.xfs, int(le.Uint16(data[2:])))
+		case record.id == biffBoundSheet && len(data) >= 8:
+			name, _ := readBIFFString(&biffReader{record: record, offset: 6}, false)
+			if data[5] == 0 {
+				w.sheets[name] = le.Uint32(data)
+			}
+		case record.id == biffSST && len(data) >= 8:
+			reader := &biffReader{record: record, offset: 8}
+			for range le.Uint32(data[4:]) {
+				value, ok := readBIFFRichString(reader)
+				if !ok {
+					break
+				}
+				w.sst = append(w.sst, value)
+			}
+		}
+		return nil
+	})
+}
+
+type biffReader struct {
+	record biffRecord
+	offset int
+}
+
+func (r *biffReader) bytes(count int) ([]byte, bool) {
+	if count < 0 || count > len(r.record.data)-r.offset {
+		return nil, false
+	}
+	data := r.record.data[r.offset : r.offset+count]
+	r.offset += count
+	return data, true
+}
+
+// nextContinue returns the start of the CONTINUE record after offset, or the
+// end of the data.
+func (r *biffReader) nextContinue() int {
+	for _, start := range r.record.continues {
+		if start > r.offset {
+			return start
+		}
+	}
+	return len(r.record.data)
+}
+
+// chars reads count characters. A string split over CONTINUE records goes on
+// with a new flags byte telling whether it is compressed.
+func (r *biffReader) chars(count int, highByte bool) (string, bool) {
+	var units []uint16
+	for len(units) < count {
+		if len(units) > 0 {
+			flags, ok := r.bytes(1)
+			if !ok {
+				return "", false
+			}
+			highByte = flags[0]&0x01 != 0
+		}
+		width := 1
+		if highByte {
+			width = 2
+		}
+		available := (r.nextContinue() - r.offset) / width
+		if available == 0 {
+			return "", false
+		}
+		data, _ := r.bytes(min(count-len(units), available) * width)
+		for i := 0; i < len(data); i += width {
+			if highByte {
+				units = append(units, binary.LittleEndian.Uint16(data[i:]))
+			} else {
+				units = append(units, uint16(data[i]))
+			}
+		}
+	}
+	return string(utf16.Decode(units)), true
+}
+
+// readBIFFString reads an XLUnicodeString, with a two byte length when long
+// is set and a one byte length otherwise.
+func readBIFFString(r *biffReader, long bool) (string, bool) {
+	var count int
+	if long {
+		data, ok := r.bytes(2)
+		if !ok {
+			return "", false
+		}
+		count = int(binary.LittleEndian.Uint16(data))
+	} else {
+		data, ok := r.bytes(1)
+		if !ok {
+			return "", false
+		}
+		count = int(data[0])
+	}
+	flags, ok := r.bytes(1)
+	if !ok {
+		return "", false
+	}
+	return r.chars(count, flags[0]&0x01 != 0)
+}
+
+// readBIFFRichString reads a shared string, skipping its formatting runs and
+// phonetic data.
+func readBIFFRichString(r *biffReader) (string, bool) {
+	le := binary.LittleEndian
+	header, ok := r.bytes(3)
+	if !ok {
+		return "", false
+	}
+	count, flags := int(le.Uint16(header)), header[2]
+	var runs, extension int
+	if flags&0x08 != 0 {
+		data, ok := r.bytes(2)
+		if !ok {
+			return "", false
+		}
+		runs = int(le.Uint16(data))
+	}
+	if flags&0x04 != 0 {
+		data, ok := r.bytes(4)
+		if !ok {
+			return "", false
+		}
+		extension = int(min(le.Uint32(data), math.MaxInt32))
+	}
+	value, ok := r.chars(count, flags&0x01 != 0)
+	if !ok {
+		return "", false
+	}
+	if _, ok := r.bytes(4 * runs); !ok {
+		return "", false
+	}
+	if _, ok := r.bytes(extension); !ok {
+		return "", false
+	}
+	return value, true
+}
+
+// rkNumber decodes the compressed number of RK and MULRK records.
+func rkNumber(rk uint32) float64 {
+	var value float64
+	if rk&0x02 != 0 {
+		value = float64(int32(rk) >> 2)
+	} else {
+		value = math.Float64frombits(uint64(rk&0xFFFFFFFC) << 32)
+	}
+	if rk&0x01 != 0 {
+		value /= 100
+	}
+	return value
+}
+
+// numberFormat returns the number format of the cell style xf.
+func (w *xlsWorkbook) numberFormat(xf int) string {
+	if xf < 0 || xf >= len(w.xfs) {
+		return ""
+	}
+	id := w.xfs[xf]
+	if format, ok := w.formats[id]; ok {
+		return format
+	}
+	if isDateFormat(id, nil) {
+		return "dd.mm.yyyy"
+	}
+	return builtinNumberFormats[id]
+}
+
+func (w *xlsWorkbook) Sheet(name string, options WorkbookOptions) (ControlSheet, error) {
+	offset, ok := w.sheets[name]
+	if !ok {
+		return ControlSheet{}, fmt.Errorf("sheet %s does not exist", name)
+	}
+	le := binary.LittleEndian
+	var control ControlSheet
+	setNumber := func(row, col, xf int, value float64) {
+		format := w.numberFormat(xf)
+		if isDateFormat(0, &format) {
+			if date, err := excelize.ExcelDateToTime(value, w.date1904); err == nil {
+				control.setDate(CellName(row, col), date)
+			}
+		}
+		text := formatNumber(value, format, w.date1904)
+		if options.RawValues {
+			text = formatGeneral(value)
+		}
+		control.Rows = setCellValue(control.Rows, row, col, text)
+	}
+	setText := func(row, col int, value string) {
+		if value != "" {
+			control.Rows = setCellValue(control.Rows, row, col, value)
+		}
+	}
+	// A formula with a string result is followed by a STRING record.
+	stringRow, stringCol := -1, -1
+	if int64(offset) >= int64(len(w.stream)) {
+		return ControlSheet{}, errXLSFormat
+	}
+	err := w.readRecords(int(offset), func(record biffRecord) error {
+		data := record.data
+		if len(data) < 6 {
+			return nil
+		}
+		row, col, xf := int(le.Uint16(data)), int(le.Uint16(data[2:])), int(le.Uint16(data[4:]))
+		switch record.id {
+		case biffLabelSST:
+			if len(data) >= 10 {
+				if index := le.Uint32(data[6:]); int64(index) < int64(len(w.sst)) {
+					setText(row, col, w.sst[index])
+				}
+			}
+		case biffLabel:
+			value, _ := readBIFFString(&biffReader{record: record, offset: 6}, true)
+			setText(row, col, value)
+		case biffNumber:
+			if len(data) >= 14 {
+				setNumber(row, col, xf, math.Float64frombits(le.Uint64(data[6:])))
+			}
+		case biffRK:
+			if len(data) >= 10 {
+				setNumber(row, col, xf, rkNumber(le.Uint32(data[6:])))
+			}
+		case biffMulRK:
+			for i := 4; i+6 <= len(data)-2; i += 6 {
+				setNumber(row, col, int(le.Uint16(data[i:])), rkNumber(le.Uint32(data[i+2:])))
+				col++
+			}
+		case biffBoolErr:
+			if len(data) >= 8 && data[7] == 0 {
+				setText(row, col, strings.ToUpper(strconv.FormatBool(data[6] != 0)))
+			}
+		case biffFormula:
+			if len(data) < 14 {
+				return nil
+			}
+			result := data[6:14]
+			if le.Uint16(result[6:]) != 0xFFFF {
+				setNumber(row, col, xf, math.Float64frombits(le.Uint64(result)))
+				return nil
+			}
+			switch result[0] {
+			case 0:
+				stringRow, stringCol = row, col
+			case 1:
+				setText(row, col, strings.ToUpper(strconv.FormatBool(result[2] != 0)))
+			}
+		case biffString:
+			if stringRow >= 0 {
+				value, _ := readBIFFString(&biffReader{record: record}, true)
+				setText(stringRow, stringCol, value)
+				stringRow, stringCol = -1, -1
+			}
+		case biffMergeCells:
+			count := int(le.Uint16(data))
+			for i := 2; i+8 <= len(data) && count > 0; i, count = i+8, count-1 {
+				control.Merged = append(control.Merged, MergedCell{
+					StartRow: int(le.Uint16(data[i:])),
+					EndRow:   int(le.Uint16(data[i+2:])),
+					StartCol: int(le.Uint16(data[i+4:])),
+					EndCol:   int(le.Uint16(data[i+6:])),
+				})
+			}
+		}
+		return nil
+	})
+	return control, err
+}
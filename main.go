@@ -1,57 +1,133 @@
 package main
 
 import (
+	"context"
+	"encoding/csv"
 	"errors"
-	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-	"io/fs"
+	"io"
+	"jubilant-spork/iul"
 	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
-	OpenLabel                      = "Выбрать"
-	SelectFolderLabel              = "Выбраная Папка:"
-	PlaceholderLabel               = "Placeholder"
-	WindowTitle                    = "Расчет Данных ИУЛ"
-	SelectTemplateLabel            = "Выбрать Фаил Шаблона:"
-	SelectTemplateButton           = "Выбрать"
-	SelectOutputLabel              = "Выбрать Фаил Назначения:"
-	SelectOutputButton             = "Выбрать"
-	RenderTemplateLabel            = "Заполнить Шаблон:"
-	RenderTemplateButton           = "Выполнить"
-	RenderCompleteLabel            = "Документ Сформирован"
-	RenderCompleteMsgTemplate      = "Документ был успешно сформирован: %s"
-	DefaultTemplatePath            = "./template.docx"
-	DefaultOutputPath              = "./result.docx"
-	WindowWidth                    = 1920
-	WindowHeight                   = 1080
-	FilenameColumnWidth            = 300
-	ChecksumColumnWidth            = 200
-	SizeColumnWidth                = 150
-	CreatedColumnWidth             = 250
-	AuthorTableColumnWidth         = 400
-	ControlTableDefaultColumnWidth = 30
+	OpenLabel                  = "folder.open"
+	SelectFolderLabel          = "folder.selected"
+	WatchFolderLabel           = "folder.watch"
+	RehashFolderButton         = "folder.rehash"
+	ExpandArchivesLabel        = "folder.expand_archives"
+	PlaceholderLabel           = "Placeholder"
+	WindowTitle                = "window.title"
+	SelectTemplateLabel        = "template.select"
+	SelectTemplateButton       = "template.select.button"
+	TemplateLibraryLabel       = "template.library"
+	TemplateFieldsLabel        = "template.fields"
+	DefaultTemplateName        = "template.default"
+	ExportTemplateButton       = "template.export.button"
+	SelectOutputLabel          = "output.select"
+	SelectOutputButton         = "output.select.button"
+	OutputPatternHint          = "output.pattern"
+	AutoVersionLabel           = "output.auto_version"
+	KeepBackupLabel            = "output.backup"
+	OverwriteLabel             = "output.overwrite.title"
+	OverwriteMsgTemplate       = "output.overwrite.message"
+	RevisionLabel              = "history.revision"
+	RevisionItemTemplate       = "history.revision.item"
+	CheckProblemsButton        = "problems.check"
+	NoProblemsLabel            = "problems.none"
+	ProblemReportButton        = "problems.report"
+	ProblemReportFileHeader    = "problems.report.file"
+	ProblemReportProblemHeader = "problems.report.problem"
+	ProblemsBlockedLabel       = "problems.blocked.title"
+	ProblemsBlockedMsgTemplate = "problems.blocked.message"
+	ProblemsWarningLabel       = "problems.warnings.title"
+	ProblemsWarningMsgTemplate = "problems.warnings.message"
+	DuplicatesLabel            = "duplicates.title"
+	DuplicatesMsgTemplate      = "duplicates.message"
+	ExcludeDuplicatesButton    = "duplicates.exclude"
+	KeepDuplicatesButton       = "duplicates.keep"
+	ProblemKeyPrefix           = "problem."
+	SeverityKeyPrefix          = "severity."
+	RenderTemplateLabel        = "render.label"
+	RenderTemplateButton       = "render.button"
+	RenderCompleteLabel        = "render.complete.title"
+	RenderCompleteMsgTemplate  = "render.complete.message"
+	StaleFilesLabel            = "render.stale.title"
+	StaleFilesMsgTemplate      = "render.stale.message"
+	ExportPackageLabel         = "package.label"
+	ExportPackageButton        = "package.button"
+	IncludePDFLabel            = "package.pdf"
+	PackageCompleteLabel       = "package.complete.title"
+	PackageCompleteMsgTemplate = "package.complete.message"
+	SigningLabel               = "signing.label"
+	SignAfterRenderLabel       = "signing.enabled"
+	SigningKeyLabel            = "signing.key"
+	SelectSigningKeyButton     = "signing.key.button"
+	SigningPasswordPlaceholder = "signing.password"
+	FirstPageRowsLabel         = "pagination.first_page_rows"
+	ContinuationPageRowsLabel  = "pagination.continuation_page_rows"
+	ItemOptionsLabel           = "items.options"
+	IncludeWorkbookLabel       = "items.include_workbook"
+	IncludeTemplateLabel       = "items.include_template"
+	SelectWorkbookLabel        = "workbook.selected"
+	OpenWorkbookButton         = "workbook.open"
+	WorkbookRawValuesLabel     = "workbook.raw_values"
+	WorkbookRecalculateLabel   = "workbook.recalculate"
+	LanguageLabel              = "language.label"
+	ControlSheetTab            = "tab.control_sheet"
+	FilesTab                   = "tab.files"
+	AuthorsTab                 = "tab.authors"
+	HistoryTab                 = "tab.history"
+	ProblemsTab                = "tab.problems"
+	TemplateCellsHint          = "control_table.template_cells"
+	FileFilterPlaceholder      = "file_table.filter"
+	FileSummaryTemplate        = "file_table.summary"
+	MainTab                    = "tab.main"
+	TemplatesTab               = "tab.templates"
+	NegativeValueError         = "error.negative_value"
+	SingleFolderError          = "error.single_folder"
+	NoManifestError            = "error.no_manifest"
+	PackageMismatchError       = "error.package_mismatch"
+	FormatProfileLabel         = "format.label"
+	FormatProfileKeyPrefix     = "format."
+	TimestampSourceLabel       = "timestamp.label"
+	TimestampSourceKeyPrefix   = "timestamp.source."
+	IssueDateLabel             = "timestamp.issue_date"
+	IssueDatePlaceholder       = "timestamp.issue_date_placeholder"
+	DefaultOutputPath          = "./result.docx"
 )
 
-var fileTableHeaders = [4]string{"Имя Файла", "Контрольная Сумма", "Размер", "Дата Создания"}
-var authorTableHeaders = [3]string{"Работа", "Имя", "Выделение"}
-var extraAuthorTitles = []string{"Разраб.", "Проверил"}
+var extraAuthorTitles = []string{"author.title.developer", "author.title.checker"}
 
-func NewFolderSelectGroup(window fyne.Window, callback func(uri fyne.ListableURI, err error)) *fyne.Container {
-	label := widget.NewLabel(SelectFolderLabel)
-	selectedFolderLabel := widget.NewLabel("")
-	button := widget.NewButton(OpenLabel, func() {
-		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+// queueEvent runs fn on the goroutine that handles the events of the window,
+// where the widget callbacks run. Drivers without an event queue run fn at once.
+func queueEvent(window fyne.Window, fn func()) {
+	if queue, ok := window.(interface{ QueueEvent(fn func()) }); ok {
+		queue.QueueEvent(fn)
+		return
+	}
+	fn()
+}
+
+func NewFolderSelectGroup(window fyne.Window, folder string, callback func(uri fyne.ListableURI, err error)) *fyne.Container {
+	label := widget.NewLabel(T(SelectFolderLabel))
+	selectedFolderLabel := widget.NewLabel(folder)
+	button := widget.NewButton(T(OpenLabel), func() {
+		folderOpenDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
 			if err != nil || uri == nil {
 				return
 			}
@@ -59,23 +135,92 @@ func NewFolderSelectGroup(window fyne.Window, callback func(uri fyne.ListableURI
 			dir := uri.Path()
 			selectedFolderLabel.SetText(dir)
 		}, window)
+		if location, err := storage.ListerForURI(storage.NewFileURI(filepath.Dir(folder))); folder != "" && err == nil {
+			folderOpenDialog.SetLocation(location)
+		}
+		folderOpenDialog.Show()
 	})
 	return container.NewVBox(label, selectedFolderLabel, button)
 }
 
-func NewConfigGroup(window fyne.Window, templateFile *string, outputFile *string) *fyne.Container {
-	selectedTemplatePath := widget.NewLabel(*templateFile)
-	selectedOutputPath := widget.NewLabel(*outputFile)
+func NewFolderOptionsGroup(watch *bool, expandArchives *bool, watchCallback func(), expandCallback func(), rehashCallback func()) *fyne.Container {
+	watchCheck := widget.NewCheck(T(WatchFolderLabel), nil)
+	watchCheck.SetChecked(*watch)
+	watchCheck.OnChanged = func(checked bool) {
+		*watch = checked
+		watchCallback()
+	}
+	expandCheck := widget.NewCheck(T(ExpandArchivesLabel), nil)
+	expandCheck.SetChecked(*expandArchives)
+	expandCheck.OnChanged = func(checked bool) {
+		*expandArchives = checked
+		expandCallback()
+	}
+	return container.NewVBox(watchCheck, expandCheck, widget.NewButton(T(RehashFolderButton), rehashCallback))
+}
+
+func templateFieldsText(templates []iul.TemplateInfo, templatePath string) string {
+	for _, template := range templates {
+		if template.Path == templatePath {
+			return T(TemplateFieldsLabel, strings.Join(template.Fields, ", "))
+		}
+	}
+	return ""
+}
+
+func NewConfigGroup(window fyne.Window, templates []iul.TemplateInfo, templateFile binding.String, outputFile *string, templateCallback func(), outputCallback func()) *fyne.Container {
+	selectedTemplatePath := widget.NewLabel("")
+	selectedOutputPath := widget.NewEntry()
+	selectedOutputPath.SetText(*outputFile)
+	selectedOutputPath.OnChanged = func(pattern string) {
+		*outputFile = pattern
+		outputCallback()
+	}
+	names := make([]string, len(templates))
+	for i, template := range templates {
+		names[i] = template.Name
+	}
+	templateFields := widget.NewLabel("")
+	templateFields.Wrapping = fyne.TextWrapWord
+	librarySelect := widget.NewSelect(names, nil)
+	templateFile.AddListener(binding.NewDataListener(func() {
+		path, _ := templateFile.Get()
+		librarySelect.Selected = ""
+		for i, template := range templates {
+			if template.Path == path {
+				librarySelect.Selected = names[i]
+			}
+		}
+		librarySelect.Refresh()
+		templateFields.SetText(templateFieldsText(templates, path))
+		if path == "" {
+			selectedTemplatePath.SetText(T(DefaultTemplateName))
+		} else {
+			selectedTemplatePath.SetText(path)
+		}
+	}))
+	librarySelect.OnChanged = func(name string) {
+		path, _ := templateFile.Get()
+		for i := range names {
+			if names[i] == name && templates[i].Path != path {
+				_ = templateFile.Set(templates[i].Path)
+				templateCallback()
+			}
+		}
+	}
 	return container.NewVBox(
-		widget.NewLabel(SelectTemplateLabel),
+		widget.NewLabel(T(TemplateLibraryLabel)),
+		librarySelect,
+		templateFields,
+		widget.NewLabel(T(SelectTemplateLabel)),
 		selectedTemplatePath,
-		widget.NewButton(SelectTemplateButton, func() {
+		widget.NewButton(T(SelectTemplateButton), func() {
 			templateOpenDialog := dialog.NewFileOpen(func(closer fyne.URIReadCloser, err error) {
 				if err != nil || closer == nil {
 					return
 				}
-				*templateFile = closer.URI().Path()
-				selectedTemplatePath.SetText(*templateFile)
+				_ = templateFile.Set(closer.URI().Path())
+				templateCallback()
 				err = closer.Close()
 				if err != nil {
 					log.Fatal(err)
@@ -84,397 +229,999 @@ func NewConfigGroup(window fyne.Window, templateFile *string, outputFile *string
 			templateOpenDialog.SetFilter(storage.NewExtensionFileFilter([]string{".doc", ".docx"}))
 			templateOpenDialog.Show()
 		}),
-		widget.NewLabel(SelectOutputLabel),
-		selectedOutputPath,
-		widget.NewButton(SelectOutputButton, func() {
-			fileSaveDialog := dialog.NewFileSave(func(closer fyne.URIWriteCloser, err error) {
+		widget.NewButton(T(ExportTemplateButton), func() {
+			templateSaveDialog := dialog.NewFileSave(func(closer fyne.URIWriteCloser, err error) {
 				if err != nil || closer == nil {
 					return
 				}
-				*outputFile = closer.URI().Path()
-				selectedOutputPath.SetText(*outputFile)
+				exportPath := closer.URI().Path()
 				err = closer.Close()
+				if err == nil {
+					err = iul.ExportBuiltinTemplate(exportPath)
+				}
 				if err != nil {
+					dialog.NewError(err, window).Show()
 					return
 				}
+				_ = templateFile.Set(exportPath)
+				templateCallback()
 			}, window)
-			fileSaveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".doc", ".docx"}))
-			fileSaveDialog.SetFileName("result.docx")
-			fileSaveDialog.Show()
+			templateSaveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".docx"}))
+			templateSaveDialog.SetFileName(iul.BuiltinTemplateName)
+			templateSaveDialog.Show()
 		}),
+		widget.NewLabel(T(SelectOutputLabel)),
+		selectedOutputPath,
+		widget.NewLabel(T(OutputPatternHint)),
+		widget.NewButton(T(SelectOutputButton), func() {
+			// Only the folder is chosen here: a save dialog would create or
+			// truncate the file before the overwrite, backup and versioning
+			// options are applied on render.
+			outputFolderDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+				if err != nil || uri == nil {
+					return
+				}
+				name := filepath.Base(*outputFile)
+				if *outputFile == "" {
+					name = filepath.Base(DefaultOutputPath)
+				}
+				selectedOutputPath.SetText(filepath.Join(uri.Path(), name))
+			}, window)
+			if location, err := storage.ListerForURI(storage.NewFileURI(filepath.Dir(*outputFile))); err == nil {
+				outputFolderDialog.SetLocation(location)
+			}
+			outputFolderDialog.Show()
+		}),
+	)
+}
+
+func newRowsEntry(rows *int) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.Itoa(*rows))
+	entry.Validator = func(s string) error {
+		value, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		if value < 0 {
+			return errors.New(T(NegativeValueError))
+		}
+		return nil
+	}
+	entry.OnChanged = func(s string) {
+		if entry.Validate() != nil {
+			return
+		}
+		*rows, _ = strconv.Atoi(s)
+	}
+	return entry
+}
+
+func NewPaginationGroup(pagination *iul.Pagination) *fyne.Container {
+	return container.NewVBox(
+		widget.NewLabel(T(FirstPageRowsLabel)),
+		newRowsEntry(&pagination.FirstPageRows),
+		widget.NewLabel(T(ContinuationPageRowsLabel)),
+		newRowsEntry(&pagination.ContinuationPageRows),
 	)
 }
 
+func NewItemOptionsGroup(options *iul.ItemOptions) *fyne.Container {
+	includeWorkbook := widget.NewCheck(T(IncludeWorkbookLabel), func(checked bool) {
+		options.IncludeWorkbook = checked
+	})
+	includeWorkbook.SetChecked(options.IncludeWorkbook)
+	includeTemplate := widget.NewCheck(T(IncludeTemplateLabel), func(checked bool) {
+		options.IncludeTemplate = checked
+	})
+	includeTemplate.SetChecked(options.IncludeTemplate)
+	return container.NewVBox(widget.NewLabel(T(ItemOptionsLabel)), includeWorkbook, includeTemplate)
+}
+
+func NewOutputOptionsGroup(autoVersion *bool, keepBackup *bool) *fyne.Container {
+	autoVersionCheck := widget.NewCheck(T(AutoVersionLabel), func(checked bool) {
+		*autoVersion = checked
+	})
+	autoVersionCheck.SetChecked(*autoVersion)
+	keepBackupCheck := widget.NewCheck(T(KeepBackupLabel), func(checked bool) {
+		*keepBackup = checked
+	})
+	keepBackupCheck.SetChecked(*keepBackup)
+	return container.NewVBox(autoVersionCheck, keepBackupCheck)
+}
+
+func NewFormatProfileGroup(profile *iul.FormatProfile, callback func()) *fyne.Container {
+	names := make([]string, len(iul.FormatProfiles))
+	for i, formatProfile := range iul.FormatProfiles {
+		names[i] = T(FormatProfileKeyPrefix + formatProfile.Name)
+	}
+	profileSelect := widget.NewSelect(names, nil)
+	profileSelect.SetSelected(T(FormatProfileKeyPrefix + profile.Name))
+	profileSelect.OnChanged = func(name string) {
+		for i := range names {
+			if names[i] == name {
+				*profile = iul.FormatProfiles[i]
+				profile.Locale = numberLocale()
+				callback()
+			}
+		}
+	}
+	return container.NewVBox(widget.NewLabel(T(FormatProfileLabel)), profileSelect)
+}
+
+func NewTimestampGroup(timestamps *iul.TimestampOptions, callback func()) *fyne.Container {
+	names := make([]string, len(iul.TimestampSources))
+	for i, source := range iul.TimestampSources {
+		names[i] = T(TimestampSourceKeyPrefix + string(source))
+	}
+	issueDate := widget.NewEntry()
+	issueDate.SetPlaceHolder(T(IssueDatePlaceholder))
+	if !timestamps.IssueDate.IsZero() {
+		issueDate.SetText(timestamps.IssueDate.Format(iul.IssueDateLayout))
+	}
+	issueDate.Validator = func(s string) error {
+		_, err := time.ParseInLocation(iul.IssueDateLayout, s, time.Local)
+		return err
+	}
+	issueDate.OnSubmitted = func(s string) {
+		value, err := time.ParseInLocation(iul.IssueDateLayout, s, time.Local)
+		if err != nil {
+			return
+		}
+		timestamps.IssueDate = value
+		callback()
+	}
+	sourceSelect := widget.NewSelect(names, nil)
+	sourceSelect.SetSelected(T(TimestampSourceKeyPrefix + string(timestamps.Source)))
+	sourceSelect.OnChanged = func(name string) {
+		for i := range names {
+			if names[i] == name {
+				timestamps.Source = iul.TimestampSources[i]
+				callback()
+			}
+		}
+	}
+	return container.NewVBox(widget.NewLabel(T(TimestampSourceLabel)), sourceSelect, widget.NewLabel(T(IssueDateLabel)), issueDate)
+}
+
 func NewRenderDocumentGroup(callback func()) *fyne.Container {
-	renderDocumentLabel := widget.NewLabel(RenderTemplateLabel)
-	renderDocumentButton := widget.NewButton(RenderTemplateButton, callback)
+	renderDocumentLabel := widget.NewLabel(T(RenderTemplateLabel))
+	renderDocumentButton := widget.NewButton(T(RenderTemplateButton), callback)
 	return container.NewVBox(renderDocumentLabel, renderDocumentButton)
 }
 
+func NewExportPackageGroup(window fyne.Window, outputFile *string, includePDF *bool, callback func(packagePath string)) *fyne.Container {
+	pdfCheck := widget.NewCheck(T(IncludePDFLabel), func(checked bool) {
+		*includePDF = checked
+	})
+	pdfCheck.SetChecked(*includePDF)
+	if !iul.PDFConverterAvailable() {
+		pdfCheck.SetChecked(false)
+		pdfCheck.Disable()
+	}
+	exportButton := widget.NewButton(T(ExportPackageButton), func() {
+		packageSaveDialog := dialog.NewFileSave(func(closer fyne.URIWriteCloser, err error) {
+			if err != nil || closer == nil {
+				return
+			}
+			packagePath := closer.URI().Path()
+			err = closer.Close()
+			if err != nil {
+				return
+			}
+			callback(packagePath)
+		}, window)
+		packageSaveDialog.SetFilter(storage.NewExtensionFileFilter([]string{iul.PackageExtension}))
+		packageSaveDialog.SetFileName(filepath.Base(iul.PackagePath(*outputFile)))
+		packageSaveDialog.Show()
+	})
+	return container.NewVBox(widget.NewLabel(T(ExportPackageLabel)), pdfCheck, exportButton)
+}
+
+func NewSigningGroup(window fyne.Window, enabled *bool, keyPath *string, password *string) *fyne.Container {
+	enabledCheck := widget.NewCheck(T(SignAfterRenderLabel), func(checked bool) {
+		*enabled = checked
+	})
+	enabledCheck.SetChecked(*enabled)
+	keyLabel := widget.NewLabel(T(SigningKeyLabel, *keyPath))
+	keyButton := widget.NewButton(T(SelectSigningKeyButton), func() {
+		keyOpenDialog := dialog.NewFileOpen(func(closer fyne.URIReadCloser, err error) {
+			if err != nil || closer == nil {
+				return
+			}
+			*keyPath = closer.URI().Path()
+			keyLabel.SetText(T(SigningKeyLabel, *keyPath))
+			err = closer.Close()
+			if err != nil {
+				return
+			}
+		}, window)
+		keyOpenDialog.SetFilter(storage.NewExtensionFileFilter([]string{".p12", ".pfx"}))
+		keyOpenDialog.Show()
+	})
+	passwordEntry := widget.NewPasswordEntry()
+	passwordEntry.SetPlaceHolder(T(SigningPasswordPlaceholder))
+	passwordEntry.SetText(*password)
+	passwordEntry.OnChanged = func(s string) {
+		*password = s
+	}
+	return container.NewVBox(widget.NewLabel(T(SigningLabel)), enabledCheck, keyLabel, keyButton, passwordEntry)
+}
+
 func NewControlSheetSelect(window fyne.Window, excelFile *string, callback func()) *fyne.Container {
-	labelText := "Выбор XLSX: %s"
-	label := widget.NewLabel(fmt.Sprintf(labelText, ""))
+	label := widget.NewLabel(T(SelectWorkbookLabel, *excelFile))
 	return container.NewVBox(
 		label,
-		widget.NewButton("Открыть", func() {
-			dialog.ShowFileOpen(func(closer fyne.URIReadCloser, err error) {
+		widget.NewButton(T(OpenWorkbookButton), func() {
+			workbookOpenDialog := dialog.NewFileOpen(func(closer fyne.URIReadCloser, err error) {
 				if err != nil || closer == nil {
 					return
 				}
 				*excelFile = closer.URI().Path()
-				label.SetText(fmt.Sprintf(labelText, *excelFile))
+				label.SetText(T(SelectWorkbookLabel, *excelFile))
 				callback()
 				err = closer.Close()
 				if err != nil {
 					return
 				}
 			}, window)
+			workbookOpenDialog.SetFilter(storage.NewExtensionFileFilter(iul.WorkbookExtensions))
+			workbookOpenDialog.Show()
 		}))
 }
 
-func moveFile(slice [][]string, src, dst int) [][]string {
-	sliceLen := len(slice)
-	value := slice[src]
-	if src == 0 && dst == -1 {
-		slice = slice[src+1:]
-		slice = append(slice, value)
-		return slice
-	}
-	if src == sliceLen-1 && dst == sliceLen {
-		slice = slice[:src]
-		slice = append([][]string{value}, slice...)
-		return slice
-	}
-	copy(slice[src:], slice[src+1:])
-	slice = slice[:len(slice)-1]
-	slice = append(slice, []string{})
-	copy(slice[dst+1:], slice[dst:])
-	slice[dst] = value
-	return slice
-}
-
-func moveAuthor(slice [][2]string, src, dst int) [][2]string {
-	sliceLen := len(slice)
-	value := slice[src]
-	if src == 0 && dst == -1 {
-		slice = slice[src+1:]
-		slice = append(slice, value)
-		return slice
-	}
-	if src == sliceLen-1 && dst == sliceLen {
-		slice = slice[:src]
-		slice = append([][2]string{value}, slice...)
-		return slice
-	}
-	copy(slice[src:], slice[src+1:])
-	slice = slice[:len(slice)-1]
-	slice = append(slice, [2]string{})
-	copy(slice[dst+1:], slice[dst:])
-	slice[dst] = value
-	return slice
+func NewWorkbookOptionsGroup(options *iul.WorkbookOptions, callback func()) *fyne.Container {
+	rawValuesCheck := widget.NewCheck(T(WorkbookRawValuesLabel), func(checked bool) {
+		options.RawValues = checked
+		callback()
+	})
+	rawValuesCheck.SetChecked(options.RawValues)
+	recalculateCheck := widget.NewCheck(T(WorkbookRecalculateLabel), func(checked bool) {
+		options.Recalculate = checked
+		callback()
+	})
+	recalculateCheck.SetChecked(options.Recalculate)
+	return container.NewVBox(rawValuesCheck, recalculateCheck)
 }
 
-func CreateFileDataTable(fileData *[][]string) *widget.Table {
-	table := &widget.Table{
-		Length: func() (rows int, cols int) {
-			rowsCount := len(*fileData)
-			if rowsCount == 0 {
-				return 0, 0
+func NewLanguageSelect(callback func(language string)) *fyne.Container {
+	languages := Languages()
+	names := make([]string, len(languages))
+	for i, language := range languages {
+		names[i] = languageName(language)
+	}
+	languageSelect := widget.NewSelect(names, nil)
+	languageSelect.SetSelected(languageName(CurrentLanguage()))
+	languageSelect.OnChanged = func(name string) {
+		for i := range names {
+			if names[i] == name && languages[i] != CurrentLanguage() {
+				callback(languages[i])
 			}
-			colsCount := len((*fileData)[0])
-			return rowsCount, colsCount
-		},
-		CreateCell: func() fyne.CanvasObject {
-			return widget.NewLabel(PlaceholderLabel)
-		},
-		UpdateCell: func(id widget.TableCellID, object fyne.CanvasObject) {},
-	}
-	table.ExtendBaseWidget(table)
-	table.UpdateCell = func(id widget.TableCellID, object fyne.CanvasObject) {
-		label := object.(*widget.Label)
-		cellContent := (*fileData)[id.Row][id.Col]
-		label.SetText(cellContent)
-	}
-	table.ShowHeaderRow = true
-	table.ShowHeaderColumn = true
-	table.SetColumnWidth(0, FilenameColumnWidth)
-	table.SetColumnWidth(1, ChecksumColumnWidth)
-	table.SetColumnWidth(2, SizeColumnWidth)
-	table.SetColumnWidth(3, CreatedColumnWidth)
-	table.UpdateHeader = func(id widget.TableCellID, template fyne.CanvasObject) {
-		label := template.(*widget.Label)
-		if id.Row < 0 {
-			label.SetText(fileTableHeaders[id.Col])
-		} else if id.Col < 0 {
-			label.SetText(strconv.Itoa(id.Row + 1))
-		} else {
-			label.SetText("")
 		}
 	}
-	return table
+	return container.NewVBox(widget.NewLabel(T(LanguageLabel)), languageSelect)
 }
 
-func CreateFileTableLayout(table *widget.Table, fileData *[][]string) *fyne.Container {
-	var selectedCell widget.TableCellID
-	table.OnSelected = func(id widget.TableCellID) {
-		selectedCell = id
-	}
-	upButton := widget.NewButtonWithIcon("", theme.MenuDropUpIcon(), func() {
-		// Move selected row up
-		if selectedCell.Row < len(*fileData) && selectedCell.Row > 0 {
-			*fileData = moveFile(*fileData, selectedCell.Row, selectedCell.Row-1)
-			table.Refresh()
-			table.Select(widget.TableCellID{Row: selectedCell.Row - 1, Col: selectedCell.Col})
-		} else {
-			*fileData = moveFile(*fileData, selectedCell.Row, selectedCell.Row-1)
-			table.Refresh()
-			table.Select(widget.TableCellID{Row: len(*fileData) - 1, Col: selectedCell.Col})
+func localizedAuthorTitles() []string {
+	titles := make([]string, len(extraAuthorTitles))
+	for i, title := range extraAuthorTitles {
+		titles[i] = T(title)
+	}
+	return titles
+}
+
+func problemText(problem iul.Problem) string {
+	text := T(ProblemKeyPrefix + problem.Rule)
+	if problem.Subject != "" || problem.Value != "" {
+		text = T(ProblemKeyPrefix+problem.Rule, problem.Subject, problem.Value, problem.Cell)
+	}
+	return T(SeverityKeyPrefix+string(problem.Severity)) + ": " + text
+}
+
+// writeProblemReport writes the problems of single files as CSV for Excel,
+// one row per problem.
+func writeProblemReport(w io.Writer, problems []iul.Problem) error {
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+	if err := writer.Write([]string{T(ProblemReportFileHeader), T(ProblemReportProblemHeader)}); err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		if problem.File == "" {
+			continue
 		}
-	})
-	downButton := widget.NewButtonWithIcon("", theme.MenuDropDownIcon(), func() {
-		// Move selected row up
-		if selectedCell.Row < len(*fileData)-1 && selectedCell.Row > -1 {
-			*fileData = moveFile(*fileData, selectedCell.Row, selectedCell.Row+1)
-			table.Refresh()
-			table.Select(widget.TableCellID{Row: selectedCell.Row + 1, Col: selectedCell.Col})
-		} else {
-			*fileData = moveFile(*fileData, selectedCell.Row, selectedCell.Row+1)
-			table.Refresh()
-			table.Select(widget.TableCellID{Row: 0, Col: selectedCell.Col})
+		if err := writer.Write([]string{problem.File, problemText(problem)}); err != nil {
+			return err
 		}
-	})
-	return container.NewBorder(nil, nil, nil, container.NewGridWithColumns(1, upButton, downButton), table)
+	}
+	writer.Flush()
+	return writer.Error()
 }
 
-func CreateControlTable(controlData *[][]string) *widget.Table {
-	table := widget.NewTableWithHeaders(
-		func() (rows int, cols int) {
-			rowsCount := len(*controlData)
-			if rowsCount == 0 {
-				return 0, 0
-			}
-			colsCount := 0
-			for rowIndex := range len(*controlData) {
-				rowLen := len((*controlData)[rowIndex])
-				if rowLen > colsCount {
-					colsCount = rowLen
-				}
-			}
-			return rowsCount, colsCount
-		},
+// NewProblemsPanel lists the problems found by the last validation.
+func NewProblemsPanel(window fyne.Window, problems binding.UntypedList, checkCallback func()) *fyne.Container {
+	noProblems := widget.NewLabel(T(NoProblemsLabel))
+	list := widget.NewListWithData(
+		problems,
 		func() fyne.CanvasObject {
-			label := widget.NewLabel(PlaceholderLabel)
-			return label
+			return widget.NewLabel(PlaceholderLabel)
 		},
-		func(id widget.TableCellID, object fyne.CanvasObject) {
-			row := (*controlData)[id.Row]
-			label := object.(*widget.Label)
-			cellContent := ""
-			if len(row) > id.Col {
-				cellContent = (*controlData)[id.Row][id.Col]
+		func(item binding.DataItem, object fyne.CanvasObject) {
+			value, err := item.(binding.Untyped).Get()
+			if problem, ok := value.(iul.Problem); ok && err == nil {
+				object.(*widget.Label).SetText(problemText(problem))
 			}
-			label.SetText(cellContent)
 		},
 	)
-	for i := range 4 {
-		table.SetColumnWidth(i, ControlTableDefaultColumnWidth)
+	problems.AddListener(binding.NewDataListener(func() {
+		if problems.Length() == 0 {
+			noProblems.Show()
+		} else {
+			noProblems.Hide()
+		}
+	}))
+	reportButton := widget.NewButton(T(ProblemReportButton), func() {
+		reportSaveDialog := dialog.NewFileSave(func(closer fyne.URIWriteCloser, err error) {
+			if err != nil || closer == nil {
+				return
+			}
+			result := make([]iul.Problem, problems.Length())
+			for i := range result {
+				result[i] = bindingValue[iul.Problem](problems, i)
+			}
+			err = writeProblemReport(closer, result)
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				dialog.NewError(err, window).Show()
+			}
+		}, window)
+		reportSaveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".csv"}))
+		reportSaveDialog.SetFileName("report.csv")
+		reportSaveDialog.Show()
+	})
+	return container.NewBorder(
+		container.NewVBox(container.NewHBox(widget.NewButton(T(CheckProblemsButton), checkCallback), reportButton), noProblems),
+		nil,
+		nil,
+		nil,
+		list,
+	)
+}
+
+func localizedStatusNames() map[iul.FileStatus]string {
+	statusNames := make(map[iul.FileStatus]string, len(iul.DefaultStatusNames))
+	for status := range iul.DefaultStatusNames {
+		statusNames[status] = T(FileStatusKeyPrefix + string(status))
 	}
-	return table
+	return statusNames
 }
 
-func CreateAuthorTable(authorsData *[][2]string, distinctAuthors *[]string) *widget.Table {
-	table := &widget.Table{
-		Length: func() (rows int, cols int) {
-			rowsCount := len(*authorsData)
-			if rowsCount == 0 {
-				return 0, 0
+// NewRevisionSelect chooses the issue the files are compared with. The newest
+// revision is shown as selected whenever the list changes.
+func NewRevisionSelect(revisions binding.UntypedList, profile *iul.FormatProfile, callback func(revision iul.Revision)) *fyne.Container {
+	revisionSelect := widget.NewSelect(nil, nil)
+	var items []iul.Revision
+	onChanged := func(option string) {
+		for i, item := range items {
+			if revisionSelect.Options[i] == option {
+				callback(item)
 			}
-			colsCount := len((*authorsData)[0])
-			return rowsCount, colsCount + 1
-		},
-		CreateCell: func() fyne.CanvasObject {
-			return container.NewVBox()
-		},
-		UpdateCell: func(id widget.TableCellID, object fyne.CanvasObject) {},
+		}
 	}
-	table.UpdateCell = func(id widget.TableCellID, object fyne.CanvasObject) {
-		row := (*authorsData)[id.Row]
-		cellContent := ""
-		if len(row) > id.Col {
-			cellContent = (*authorsData)[id.Row][id.Col]
+	revisions.AddListener(binding.NewDataListener(func() {
+		items = make([]iul.Revision, revisions.Length())
+		options := make([]string, len(items))
+		for i := range items {
+			items[i] = bindingValue[iul.Revision](revisions, i)
+			options[i] = T(RevisionItemTemplate, items[i].Number, profile.FormatDate(items[i].GeneratedAt))
 		}
-		box := object.(*fyne.Container)
-		box.RemoveAll()
-		if id.Col == 0 {
-
-			titleSelect := widget.NewSelect(*distinctAuthors, func(s string) {
-				(*authorsData)[id.Row][id.Col] = s
-			})
-			titleSelect.Selected = cellContent
-			box.Add(titleSelect)
-		} else if id.Col == 1 {
-			entry := widget.NewEntry()
-			entry.SetText(cellContent)
-			entry.OnChanged = func(s string) {
-				(*authorsData)[id.Row][id.Col] = s
-			}
-			box.Add(entry)
-		} else {
-			box.Add(widget.NewLabel(""))
+		revisionSelect.OnChanged = nil
+		revisionSelect.Options = options
+		revisionSelect.Selected = ""
+		if len(options) > 0 {
+			revisionSelect.Selected = options[len(options)-1]
 		}
+		revisionSelect.Refresh()
+		revisionSelect.OnChanged = onChanged
+	}))
+	return container.NewVBox(widget.NewLabel(T(RevisionLabel)), revisionSelect)
+}
+
+func openChecksumCache() *iul.ChecksumCache {
+	cachePath, err := iul.DefaultChecksumCachePath()
+	if err != nil {
+		log.Printf("Checksum cache is disabled due to %s", err)
+		return nil
 	}
+	cache, err := iul.OpenChecksumCache(cachePath)
+	if err != nil {
+		log.Printf("Checksum cache is disabled due to %s", err)
+		return nil
+	}
+	return cache
+}
 
-	table.UpdateHeader = func(id widget.TableCellID, template fyne.CanvasObject) {
-		label := template.(*widget.Label)
-		if id.Row < 0 {
-			label.SetText(authorTableHeaders[id.Col])
-		} else if id.Col < 0 {
-			label.SetText(strconv.Itoa(id.Row + 1))
-		} else {
-			label.SetText("")
-		}
+func openProjectStore() *iul.ProjectStore {
+	storeDir, err := iul.DefaultProjectStoreDir()
+	if err != nil {
+		log.Printf("Project settings are not remembered due to %s", err)
+		return nil
 	}
-	table.ExtendBaseWidget(table)
-	table.ShowHeaderRow = true
-	table.ShowHeaderColumn = true
-	table.SetColumnWidth(0, AuthorTableColumnWidth)
-	table.SetColumnWidth(1, AuthorTableColumnWidth)
-	table.SetColumnWidth(2, AuthorTableColumnWidth)
-	return table
+	return iul.NewProjectStore(storeDir)
 }
 
-func CreateAuthorTableLayout(table *widget.Table, authorsData *[][2]string) *fyne.Container {
-	var selectedCell = widget.TableCellID{Row: -1, Col: -1}
-	table.OnSelected = func(id widget.TableCellID) {
-		if id.Col != 0 {
-			table.Select(widget.TableCellID{Row: id.Row, Col: 2})
+// loadTemplates lists the built-in template, which has an empty path,
+// followed by the template library.
+func loadTemplates() []iul.TemplateInfo {
+	templates := []iul.TemplateInfo{{Name: T(DefaultTemplateName)}}
+	if fields, err := iul.TemplateFields(""); err == nil {
+		templates[0].Fields = fields
+	}
+	libraryDir, err := iul.DefaultTemplateLibraryDir()
+	if err != nil {
+		log.Printf("Template library is unavailable due to %s", err)
+		return templates
+	}
+	library, err := iul.LoadTemplateLibrary(libraryDir)
+	if err != nil {
+		log.Printf("Failed to load template library %s due to %s", libraryDir, err)
+	}
+	return append(templates, library...)
+}
+
+func main() {
+	mainApp := app.NewWithID(AppID)
+	preferences := mainApp.Preferences()
+	SetLanguage(preferences.StringWithFallback(LanguagePreference, DefaultLanguage))
+	window := mainApp.NewWindow(T(WindowTitle))
+	restoreWindowSize(window, preferences)
+
+	project := new(iul.Project)
+	bindings := NewProjectBinding(project)
+	workingDir, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+	templateFile := preferences.String(TemplatePreference)
+	if _, err := os.Stat(templateFile); err != nil {
+		templateFile = ""
+	}
+	templatePath := binding.BindString(&templateFile)
+	// templateCells are the control sheet cells the chosen template uses.
+	templateCells := binding.NewStringList()
+	templatePath.AddListener(binding.NewDataListener(func() {
+		fields, err := iul.TemplateFields(templateFile)
+		if err != nil {
+			log.Printf("Failed to read the fields of %s due to %s", templateFile, err)
 		}
-		selectedCell = id
+		_ = templateCells.Set(iul.ControlCells(fields))
+	}))
+	var outputFile = preferences.StringWithFallback(OutputPreference, path.Join(workingDir, DefaultOutputPath))
+	var renderedFile = outputFile
+	var autoVersion bool
+	var keepBackup bool
+	var itemOptions iul.ItemOptions
+	var workbookOptions iul.WorkbookOptions
+	var formatProfile = iul.FindFormatProfile(iul.DefaultFormatProfile)
+	formatProfile.Locale = numberLocale()
+	var pagination = iul.Pagination{
+		FirstPageRows:        iul.DefaultFirstPageRows,
+		ContinuationPageRows: iul.DefaultContinuationPageRows,
 	}
-	upButton := widget.NewButtonWithIcon("", theme.MenuDropUpIcon(), func() {
-		// Move selected row up
-		if selectedCell.Row < len(*authorsData) && selectedCell.Row > 0 {
-			*authorsData = moveAuthor(*authorsData, selectedCell.Row, selectedCell.Row-1)
-			table.Refresh()
-			table.Select(widget.TableCellID{Row: selectedCell.Row - 1, Col: selectedCell.Col})
-		} else {
-			*authorsData = moveAuthor(*authorsData, selectedCell.Row, selectedCell.Row-1)
-			table.Refresh()
-			table.Select(widget.TableCellID{Row: len(*authorsData) - 1, Col: selectedCell.Col})
+	var timestamps = iul.TimestampOptions{Source: iul.TimestampModified}
+	var watchFolder bool
+	var expandArchives bool
+	var includePDF bool
+	var signAfterRender bool
+	var signingKey string
+	var signingPassword string
+	var stopWatching context.CancelFunc = func() {}
+	// excludedFiles are the files of the project folder left out of the list.
+	var excludedFiles []string
+	checksumCache := openChecksumCache()
+	projectStore := openProjectStore()
+	revisions := binding.NewUntypedList()
+	validationRulesPath, err := iul.DefaultValidationRulesPath()
+	if err != nil {
+		log.Printf("Validation rules are not configurable due to %s", err)
+	}
+
+	scanOptions := func() iul.ScanOptions {
+		return iul.ScanOptions{
+			Timestamps:     timestamps,
+			Exclude:        append([]string{renderedFile, iul.ManifestPath(renderedFile)}, excludedFiles...),
+			Output:         iul.NewOutputMatcher(outputFile, formatProfile.FormatControl(project.Control)),
+			Cache:          checksumCache,
+			ExpandArchives: expandArchives,
 		}
-	})
-	downButton := widget.NewButtonWithIcon("", theme.MenuDropDownIcon(), func() {
-		// Move selected row up
-		if selectedCell.Row < len(*authorsData)-1 && selectedCell.Row > -1 {
-			*authorsData = moveAuthor(*authorsData, selectedCell.Row, selectedCell.Row+1)
-			table.Refresh()
-			table.Select(widget.TableCellID{Row: selectedCell.Row + 1, Col: selectedCell.Col})
-		} else {
-			*authorsData = moveAuthor(*authorsData, selectedCell.Row, selectedCell.Row+1)
-			table.Refresh()
-			table.Select(widget.TableCellID{Row: 0, Col: selectedCell.Col})
+	}
+	saveChecksumCache := func() {
+		if err := checksumCache.Save(); err != nil {
+			log.Printf("Failed to save checksum cache due to %s", err)
 		}
-	})
-	deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
-		if selectedCell.Row < 0 {
+	}
+	scanProjectFolder := func(forceRehash bool) error {
+		if project.Folder == "" {
+			return nil
+		}
+		options := scanOptions()
+		options.ForceRehash = forceRehash
+		files, err := iul.Scan(context.Background(), project.Folder, options)
+		if err != nil {
+			return err
+		}
+		saveChecksumCache()
+		project.Files = files
+		bindings.Sync()
+		return nil
+	}
+	watchProjectFolder := func() {
+		stopWatching()
+		stopWatching = func() {}
+		if !watchFolder || project.Folder == "" {
 			return
 		}
-		*authorsData = append((*authorsData)[:selectedCell.Row], (*authorsData)[selectedCell.Row+1:]...)
-		table.Refresh()
-	})
-	return container.NewBorder(nil, nil, nil, container.NewGridWithColumns(1, upButton, downButton, deleteButton), table)
-}
-
-func updateFileTable(dir string, fileTable *widget.Table, fileData *[][]string) error {
-	files, err := os.ReadDir(dir)
-	if err != nil {
-		return err
+		ctx, cancel := context.WithCancel(context.Background())
+		stopWatching = cancel
+		folder := project.Folder
+		// The watcher rescans with the options of the moment it started and
+		// hands the files over to the event goroutine, which owns the project.
+		options := scanOptions()
+		go func() {
+			err := iul.Watch(ctx, folder, func(names []string) {
+				changed, removed, err := iul.Rescan(ctx, folder, names, options)
+				if err != nil {
+					log.Printf("Failed to rescan %s due to %s", folder, err)
+					return
+				}
+				saveChecksumCache()
+				queueEvent(window, func() {
+					if ctx.Err() != nil {
+						return
+					}
+					project.UpdateFiles(changed, removed)
+					bindings.Sync()
+				})
+			})
+			if err != nil {
+				queueEvent(window, func() {
+					dialog.NewError(err, window).Show()
+				})
+			}
+		}()
 	}
-	var newFileData [][]string
-	for _, file := range files {
-		checksum, fileSize, createdAt, err := calculateChecksum(file.Name(), dir)
+	selectRevision := func(revision iul.Revision) {
+		previous, err := iul.LoadManifest(revision.Path)
 		if err != nil {
+			dialog.NewError(err, window).Show()
+			return
+		}
+		project.Previous = previous
+		bindings.Sync()
+	}
+	// loadHistory lists the revisions of the project and compares the files
+	// with the latest one.
+	loadHistory := func() {
+		project.Previous = nil
+		var history []iul.Revision
+		if projectStore != nil && project.Folder != "" {
+			var err error
+			history, err = projectStore.Revisions(project.Folder)
+			if err != nil {
+				log.Printf("Failed to load history of %s due to %s", project.Folder, err)
+			}
+		}
+		_ = revisions.Set(toUntyped(history))
+		if len(history) == 0 {
+			bindings.Sync()
+			return
+		}
+		selectRevision(history[len(history)-1])
+	}
+	openProjectFolder := func(folder string) error {
+		project.Folder = folder
+		excludedFiles = nil
+		if err := scanProjectFolder(false); err != nil {
 			return err
 		}
-		newFileData = append(newFileData, []string{file.Name(), checksum, fileSize, createdAt})
+		preferences.SetString(LastFolderPreference, folder)
+		preferences.SetString(LastWorkbookPreference, project.WorkbookPath)
+		project.SetBaseline()
+		bindings.Sync()
+		watchProjectFolder()
+		loadHistory()
+		if projectStore == nil {
+			return nil
+		}
+		settings, err := projectStore.Load(folder)
+		if err != nil {
+			log.Printf("Failed to load settings of %s due to %s", folder, err)
+			return nil
+		}
+		if _, err := os.Stat(settings.Template); settings.Template != "" && err == nil {
+			_ = templatePath.Set(settings.Template)
+		}
+		return nil
 	}
-	*fileData = newFileData
-	fileTable.Refresh()
-
-	return nil
-}
-
-func searchExcel(dir string, fileName string) string {
-	var foundFile string
-	err := filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+	rememberTemplate := func() {
+		preferences.SetString(TemplatePreference, templateFile)
+		if projectStore == nil || project.Folder == "" {
+			return
+		}
+		settings, err := projectStore.Load(project.Folder)
+		if err == nil {
+			settings.Template = templateFile
+			err = projectStore.Save(settings)
+		}
+		if err != nil {
+			log.Printf("Failed to save settings of %s due to %s", project.Folder, err)
+		}
+	}
+	// excludeOutputFiles drops the output documents from the list once the
+	// output pattern or the control cells naming them change.
+	excludeOutputFiles := func() {
+		if project.Folder != "" {
+			output := scanOptions().Output
+			project.Files = slices.DeleteFunc(project.Files, func(file iul.CheckedFile) bool {
+				return output.Match(filepath.Join(project.Folder, file.FileName))
+			})
+		}
+		bindings.Sync()
+		watchProjectFolder()
+	}
+	checkWorkbook := func() error {
+		if project.WorkbookPath == "" {
+			return nil
+		}
+		workbook, err := iul.ChecksumPath(context.Background(), project.WorkbookPath, timestamps)
 		if err != nil {
-			fmt.Println("Error:", err)
 			return err
 		}
-
-		if info.Name() == fileName {
-			foundFile = path
-			fmt.Println("File found:", path)
+		project.Workbook = workbook
+		return nil
+	}
+	loadWorkbook := func() error {
+		if err := checkWorkbook(); err != nil {
+			return err
 		}
-
+		control, authors, err := iul.ReadWorkbook(context.Background(), project.WorkbookPath, workbookOptions)
+		if err != nil {
+			return err
+		}
+		project.SetWorkbookData(control, authors, localizedAuthorTitles())
+		excludeOutputFiles()
+		preferences.SetString(LastWorkbookPreference, project.WorkbookPath)
 		return nil
-	})
-	if err != nil {
-		return ""
 	}
 
-	return foundFile
-}
-
-func main() {
-	mainApp := app.New()
-	window := mainApp.NewWindow(WindowTitle)
-	window.Resize(fyne.NewSize(WindowWidth, WindowHeight))
-
-	var fileData [][]string
-	var controlData [][]string
-	var authorData [][2]string
-	var distinctAuthors []string
-	var excelFileName, excelFileCheck, excelFileCreated, excelSize string
-	workingDir, err := os.Getwd()
-	if err != nil {
-		log.Fatal(err)
-	}
-	var templateFile = path.Join(workingDir, DefaultTemplatePath)
-	var outputFile = path.Join(workingDir, DefaultOutputPath)
-	var excelFile = ""
-
-	controlTable := CreateControlTable(&controlData)
-	fileTable := CreateFileDataTable(&fileData)
-	fileTableLayout := CreateFileTableLayout(fileTable, &fileData)
-	authorTable := CreateAuthorTable(&authorData, &distinctAuthors)
-	authorTableLayout := CreateAuthorTableLayout(authorTable, &authorData)
-	controlGroup := container.NewVBox(
-		NewFolderSelectGroup(window, func(uri fyne.ListableURI, err error) {
-			err = updateFileTable(uri.Path(), fileTable, &fileData)
+	renderDocument := func(outputPath string) {
+		err := iul.Render(context.Background(), project, iul.RenderOptions{
+			TemplatePath: templateFile,
+			OutputPath:   outputPath,
+			KeepBackup:   keepBackup,
+			Items:        itemOptions,
+			Pagination:   pagination,
+			Profile:      formatProfile,
+			Timestamps:   timestamps,
+			Previous:     project.Previous,
+			StatusNames:  localizedStatusNames(),
+		})
+		if err != nil {
+			dialog.NewError(err, window).Show()
+			return
+		}
+		renderedFile = outputPath
+		watchProjectFolder()
+		if projectStore != nil && project.Folder != "" {
+			if _, err := projectStore.SaveRevision(project.Folder, iul.ManifestPath(outputPath)); err != nil {
+				log.Printf("Failed to save revision of %s due to %s", project.Folder, err)
+			}
+			loadHistory()
+		}
+		project.SetBaseline()
+		bindings.Sync()
+		if signAfterRender {
+			signer, err := iul.LoadPKCS12Signer(signingKey, signingPassword)
+			if err == nil {
+				_, err = iul.SignManifest(context.Background(), signer, iul.ManifestPath(outputPath))
+			}
 			if err != nil {
 				dialog.NewError(err, window).Show()
 				return
 			}
-		}),
-		NewControlSheetSelect(window, &excelFile, func() {
-			controlData, authorData = ExtractExcelFileData(excelFile)
-			controlTable.Refresh()
-			authorTable.Refresh()
-		}),
-		NewRenderDocumentGroup(func() {
-			renderTemplate(fileData, controlData, authorData, excelFileName, excelFileCheck, excelSize, excelFileCreated, &templateFile, &outputFile)
+		}
+		dialog.NewInformation(
+			T(RenderCompleteLabel),
+			T(RenderCompleteMsgTemplate, outputPath),
+			window,
+		).Show()
+	}
+
+	// confirmOverwrite resolves the output pattern and asks before replacing an
+	// existing document that is not backed up.
+	confirmOverwrite := func() {
+		outputPath := iul.ResolveOutputPath(outputFile, formatProfile.FormatControl(project.Control), autoVersion)
+		if _, err := os.Stat(outputPath); err != nil || keepBackup {
+			renderDocument(outputPath)
+			return
+		}
+		dialog.NewConfirm(
+			T(OverwriteLabel),
+			T(OverwriteMsgTemplate, outputPath),
+			func(confirmed bool) {
+				if confirmed {
+					renderDocument(outputPath)
+				}
+			},
+			window,
+		).Show()
+	}
+
+	checkStaleFiles := func() {
+		if project.Folder == "" {
+			confirmOverwrite()
+			return
+		}
+		staleFiles, err := iul.StaleFiles(project.Folder, project.Files, scanOptions())
+		if err != nil {
+			dialog.NewError(err, window).Show()
+			return
+		}
+		if len(staleFiles) == 0 {
+			confirmOverwrite()
+			return
+		}
+		dialog.NewConfirm(
+			T(StaleFilesLabel),
+			T(StaleFilesMsgTemplate, strings.Join(staleFiles, "\n")),
+			func(confirmed bool) {
+				if confirmed {
+					confirmOverwrite()
+				}
+			},
+			window,
+		).Show()
+	}
+	validateProject := func() []iul.Problem {
+		rules := iul.DefaultValidationRules
+		if validationRulesPath != "" {
+			var err error
+			rules, err = iul.LoadValidationRules(validationRulesPath)
+			if err != nil {
+				log.Printf("Failed to load validation rules %s due to %s", validationRulesPath, err)
+			}
+		}
+		rules = rules.LocalizeTitles(localizedAuthorTitles())
+		templateFields, err := iul.TemplateFields(templateFile)
+		if err != nil {
+			log.Printf("Failed to read the fields of %s due to %s", templateFile, err)
+		}
+		result := iul.Validate(project, templateFields, rules)
+		bindings.SetProblems(result)
+		return result
+	}
+	// confirmProblems blocks rendering on errors and asks to confirm warnings
+	// before the remaining checks. Problems of the acknowledged rules were
+	// already confirmed and are not asked about again.
+	confirmProblems := func(acknowledged ...string) {
+		result := slices.DeleteFunc(validateProject(), func(problem iul.Problem) bool {
+			return slices.Contains(acknowledged, problem.Rule)
+		})
+		if len(result) == 0 {
+			checkStaleFiles()
+			return
+		}
+		texts := make([]string, len(result))
+		for i, problem := range result {
+			texts[i] = problemText(problem)
+		}
+		if iul.HasErrors(result) {
 			dialog.NewInformation(
-				RenderCompleteLabel,
-				fmt.Sprintf(RenderCompleteMsgTemplate, outputFile),
+				T(ProblemsBlockedLabel),
+				T(ProblemsBlockedMsgTemplate, strings.Join(texts, "\n")),
 				window,
 			).Show()
-		}),
-	)
+			return
+		}
+		dialog.NewConfirm(
+			T(ProblemsWarningLabel),
+			T(ProblemsWarningMsgTemplate, strings.Join(texts, "\n")),
+			func(confirmed bool) {
+				if confirmed {
+					checkStaleFiles()
+				}
+			},
+			window,
+		).Show()
+	}
+	// validateBeforeRender offers to exclude duplicate files from the list,
+	// then validates the project.
+	validateBeforeRender := func() {
+		duplicates := iul.Duplicates(project.Files)
+		if len(duplicates) == 0 {
+			confirmProblems()
+			return
+		}
+		var texts []string
+		for _, file := range project.Files {
+			if original, ok := duplicates[file.FileName]; ok {
+				texts = append(texts, file.FileName+" = "+original)
+			}
+		}
+		dialog.NewCustomConfirm(
+			T(DuplicatesLabel),
+			T(ExcludeDuplicatesButton),
+			T(KeepDuplicatesButton),
+			widget.NewLabel(T(DuplicatesMsgTemplate, strings.Join(texts, "\n"))),
+			func(exclude bool) {
+				if exclude {
+					for name := range duplicates {
+						excludedFiles = append(excludedFiles, filepath.Join(project.Folder, name))
+					}
+					project.Files = iul.RemoveDuplicates(project.Files)
+					bindings.Sync()
+					watchProjectFolder()
+				}
+				confirmProblems(iul.RuleDuplicateFile)
+			},
+			window,
+		).Show()
+	}
+
+	// openProject opens the folder with its workbook, if any, and validates
+	// the project.
+	openProject := func(folder string, workbookPath string) error {
+		project.WorkbookPath = workbookPath
+		if err := openProjectFolder(folder); err != nil {
+			return err
+		}
+		if project.WorkbookPath != "" {
+			if err := loadWorkbook(); err != nil {
+				return err
+			}
+		}
+		validateProject()
+		return nil
+	}
+
+	exportPackage := func(packagePath string) {
+		manifestPath := iul.ManifestPath(renderedFile)
+		if _, err := os.Stat(manifestPath); err != nil {
+			dialog.NewError(errors.New(T(NoManifestError, manifestPath)), window).Show()
+			return
+		}
+		err := iul.ExportPackage(context.Background(), manifestPath, packagePath, iul.PackageOptions{PDF: includePDF})
+		var mismatchErr *iul.PackageMismatchError
+		if errors.As(err, &mismatchErr) {
+			names := make([]string, len(mismatchErr.Mismatches))
+			for i, mismatch := range mismatchErr.Mismatches {
+				names[i] = mismatch.FileName
+			}
+			err = errors.New(T(PackageMismatchError, strings.Join(names, "\n")))
+		}
+		if err != nil {
+			dialog.NewError(err, window).Show()
+			return
+		}
+		dialog.NewInformation(
+			T(PackageCompleteLabel),
+			T(PackageCompleteMsgTemplate, packagePath),
+			window,
+		).Show()
+	}
+
+	var fileTable *FileTable
+	var buildContent func() fyne.CanvasObject
+	buildContent = func() fyne.CanvasObject {
+		controlTable := CreateControlTable(bindings, &formatProfile, templateCells)
+		fileTable = CreateFileDataTable(bindings, &formatProfile, preferences)
+		fileTableLayout := CreateFileTableLayout(fileTable, bindings, &formatProfile)
+		authorTable := CreateAuthorTable(bindings, preferences)
+		authorTableLayout := CreateAuthorTableLayout(authorTable, bindings)
+		settingsDialog := NewSettingsDialog(window, preferences, func(language string) {
+			SetLanguage(language)
+			preferences.SetString(LanguagePreference, language)
+			formatProfile.Locale = numberLocale()
+			window.SetTitle(T(WindowTitle))
+			window.SetContent(buildContent())
+		}, func() {
+			window.SetContent(buildContent())
+		})
+		controlGroup := container.NewVBox(
+			widget.NewButtonWithIcon(T(SettingsButton), theme.SettingsIcon(), settingsDialog.Show),
+			NewFolderSelectGroup(window, project.Folder, func(uri fyne.ListableURI, err error) {
+				err = openProjectFolder(uri.Path())
+				if err != nil {
+					dialog.NewError(err, window).Show()
+					return
+				}
+				validateProject()
+			}),
+			NewFolderOptionsGroup(&watchFolder, &expandArchives, watchProjectFolder, func() {
+				if err := scanProjectFolder(false); err != nil {
+					dialog.NewError(err, window).Show()
+				}
+			}, func() {
+				if err := scanProjectFolder(true); err != nil {
+					dialog.NewError(err, window).Show()
+				}
+			}),
+			NewControlSheetSelect(window, &project.WorkbookPath, func() {
+				if err := loadWorkbook(); err != nil {
+					dialog.NewError(err, window).Show()
+				}
+				validateProject()
+			}),
+			NewWorkbookOptionsGroup(&workbookOptions, func() {
+				if project.WorkbookPath == "" {
+					return
+				}
+				if err := loadWorkbook(); err != nil {
+					dialog.NewError(err, window).Show()
+				}
+				validateProject()
+			}),
+			NewRenderDocumentGroup(validateBeforeRender),
+			NewSigningGroup(window, &signAfterRender, &signingKey, &signingPassword),
+			NewExportPackageGroup(window, &renderedFile, &includePDF, exportPackage),
+		)
+		tabs := container.NewAppTabs(
+			container.NewTabItem(T(ControlSheetTab), CreateControlTableLayout(controlTable, bindings, &formatProfile)),
+			container.NewTabItem(T(FilesTab), fileTableLayout),
+			container.NewTabItem(T(AuthorsTab), authorTableLayout),
+			container.NewTabItem(T(ProblemsTab), NewProblemsPanel(window, bindings.Problems, func() {
+				validateProject()
+			})),
+			container.NewTabItem(T(HistoryTab), container.NewBorder(
+				NewRevisionSelect(revisions, &formatProfile, selectRevision),
+				nil,
+				nil,
+				nil,
+				CreateChangesTable(bindings, &formatProfile, preferences),
+			)),
+		)
+		controlTabs := container.NewAppTabs(
+			container.NewTabItem(T(MainTab), controlGroup),
+			container.NewTabItem(T(TemplatesTab), container.NewVBox(
+				NewConfigGroup(window, loadTemplates(), templatePath, &outputFile, rememberTemplate, func() {
+					preferences.SetString(OutputPreference, outputFile)
+					excludeOutputFiles()
+				}),
+				NewOutputOptionsGroup(&autoVersion, &keepBackup),
+				NewItemOptionsGroup(&itemOptions),
+				NewPaginationGroup(&pagination),
+				NewFormatProfileGroup(&formatProfile, func() {
+					fileTable.Update()
+					controlTable.Refresh()
+				}),
+				NewTimestampGroup(&timestamps, func() {
+					err := scanProjectFolder(false)
+					if err == nil {
+						err = checkWorkbook()
+					}
+					if err != nil {
+						dialog.NewError(err, window).Show()
+					}
+				}),
+			)),
+		)
+		return container.NewBorder(
+			nil,
+			nil,
+			controlTabs,
+			nil,
+			tabs,
+		)
+	}
 	window.SetOnDropped(func(position fyne.Position, uris []fyne.URI) {
 		if len(uris) != 1 {
 			dialog.NewError(
-				errors.New("Можно импортировать только 1 папку."),
+				errors.New(T(SingleFolderError)),
 				window,
 			).Show()
 			return
@@ -487,74 +1234,26 @@ func main() {
 		}
 		if !info.IsDir() {
 			dialog.NewError(
-				errors.New("Можно импортировать только 1 папку."),
+				errors.New(T(SingleFolderError)),
 				window,
 			).Show()
 			return
 		}
-		excelFile = searchExcel(filepath.Join(folderUri.Path(), "../.."), folderUri.Name()+".xlsx")
-
-		err = updateFileTable(
-			folderUri.Path(),
-			fileTable,
-			&fileData,
-		)
-		if err != nil {
+		workbookPath := iul.SearchWorkbook(filepath.Join(folderUri.Path(), "../.."), folderUri.Name())
+		if err := openProject(folderUri.Path(), workbookPath); err != nil {
 			dialog.NewError(err, window).Show()
-			return
-		}
-		if excelFile != "" {
-			excelFileName = filepath.Base(excelFile)
-			excelFileCheck, excelSize, excelFileCreated, err = calculateChecksum(
-				excelFileName,
-				filepath.Dir(excelFile),
-			)
-			if err != nil {
-				dialog.NewError(err, window).Show()
-				return
-			}
-			controlData, authorData = ExtractExcelFileData(excelFile)
-			distinctAuthors = make([]string, 0)
-			seen := make(map[string]bool)
-			for _, row := range authorData {
-				author := row[0]
-				if !seen[author] {
-					seen[author] = true
-					distinctAuthors = append(distinctAuthors, author)
-				}
-
-			}
-			for _, title := range extraAuthorTitles {
-				distinctAuthors = append(distinctAuthors, title)
-			}
-			authorDataLen := len(authorData)
-			for i := range authorData {
-				if i < authorDataLen/2 {
-					authorData[i][0] = extraAuthorTitles[0]
-				} else {
-					authorData[i][0] = extraAuthorTitles[1]
-				}
-			}
-			controlTable.Refresh()
-			authorTable.Refresh()
 		}
 	})
-	tabs := container.NewAppTabs(
-		container.NewTabItem("Лист Управления", controlTable),
-		container.NewTabItem("Фаилы", fileTableLayout),
-		container.NewTabItem("Авторы", authorTableLayout),
-	)
-	controlTabs := container.NewAppTabs(
-		container.NewTabItem("Основное", controlGroup),
-		container.NewTabItem("Шаблоны", NewConfigGroup(window, &templateFile, &outputFile)),
-	)
-	window.SetContent(
-		container.NewBorder(
-			nil,
-			nil,
-			controlTabs,
-			nil,
-			tabs,
-		))
+	lastFolder := preferences.String(LastFolderPreference)
+	if info, err := os.Stat(lastFolder); err == nil && info.IsDir() && preferences.BoolWithFallback(ReopenFolderPreference, DefaultReopenFolder) {
+		workbookPath := preferences.String(LastWorkbookPreference)
+		if _, err := os.Stat(workbookPath); err != nil {
+			workbookPath = ""
+		}
+		if err := openProject(lastFolder, workbookPath); err != nil {
+			dialog.NewError(err, window).Show()
+		}
+	}
+	window.SetContent(buildContent())
 	window.ShowAndRun()
 }
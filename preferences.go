@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"slices"
+)
+
+const (
+	AppID                   = "io.github.jubilant-spork"
+	DefaultWindowWidth      = 1280
+	DefaultWindowHeight     = 800
+	MinWindowWidth          = 640
+	MinWindowHeight         = 480
+	WindowWidthPreference   = "window.width"
+	WindowHeightPreference  = "window.height"
+	LanguagePreference      = "language"
+	LastFolderPreference    = "folder.last"
+	LastWorkbookPreference  = "workbook.last"
+	ReopenFolderPreference  = "folder.reopen"
+	TemplatePreference      = "template"
+	OutputPreference        = "output"
+	FileColumnsPreference   = "columns.files"
+	ChangeColumnsPreference = "columns.changes"
+	AuthorColumnsPreference = "columns.authors"
+	SettingsButton          = "settings.button"
+	SettingsLabel           = "settings.title"
+	SettingsCloseButton     = "settings.close"
+	ReopenFolderLabel       = "settings.reopen_folder"
+	ResetColumnWidthsButton = "settings.reset_columns"
+	ResetWindowSizeButton   = "settings.reset_window"
+	DefaultReopenFolder     = true
+)
+
+var columnPreferences = []string{FileColumnsPreference, ChangeColumnsPreference, AuthorColumnsPreference}
+
+// restoreWindowSize resizes the window to the size it had when it was last
+// closed and remembers the size on close. Fyne does not expose the window
+// position, so only the size is kept.
+func restoreWindowSize(window fyne.Window, preferences fyne.Preferences) {
+	width := preferences.FloatWithFallback(WindowWidthPreference, DefaultWindowWidth)
+	height := preferences.FloatWithFallback(WindowHeightPreference, DefaultWindowHeight)
+	window.Resize(fyne.NewSize(float32(max(width, MinWindowWidth)), float32(max(height, MinWindowHeight))))
+	window.SetOnClosed(func() {
+		size := window.Canvas().Size()
+		preferences.SetFloat(WindowWidthPreference, float64(size.Width))
+		preferences.SetFloat(WindowHeightPreference, float64(size.Height))
+	})
+}
+
+// persistColumnWidths sizes the columns as they were left and remembers the
+// widths the header dividers are dragged to. defaults are used while nothing,
+// or a different number of columns, is stored under key. Call it after the
+// table's UpdateHeader is set.
+func persistColumnWidths(table *widget.Table, preferences fyne.Preferences, key string, defaults []float32) {
+	widths := preferences.FloatList(key)
+	if len(widths) != len(defaults) {
+		widths = make([]float64, len(defaults))
+		for i, width := range defaults {
+			widths[i] = float64(width)
+		}
+	}
+	for i, width := range widths {
+		table.SetColumnWidth(i, float32(width))
+	}
+	updateHeader := table.UpdateHeader
+	table.UpdateHeader = func(id widget.TableCellID, template fyne.CanvasObject) {
+		updateHeader(id, template)
+		if id.Row >= 0 || id.Col < 0 || id.Col >= len(widths) {
+			return
+		}
+		// Column headers are laid out with the width of their column.
+		width := float64(template.Size().Width)
+		if width > 0 && width != widths[id.Col] {
+			widths[id.Col] = width
+			preferences.SetFloatList(key, slices.Clone(widths))
+		}
+	}
+}
+
+// NewSettingsDialog edits the application preferences; changes apply at once.
+// resetCallback rebuilds the window content after the column widths are reset.
+func NewSettingsDialog(window fyne.Window, preferences fyne.Preferences, languageCallback func(language string), resetCallback func()) dialog.Dialog {
+	var settingsDialog dialog.Dialog
+	reopenCheck := widget.NewCheck(T(ReopenFolderLabel), func(checked bool) {
+		preferences.SetBool(ReopenFolderPreference, checked)
+	})
+	reopenCheck.SetChecked(preferences.BoolWithFallback(ReopenFolderPreference, DefaultReopenFolder))
+	content := container.NewVBox(
+		NewLanguageSelect(func(language string) {
+			settingsDialog.Hide()
+			languageCallback(language)
+		}),
+		reopenCheck,
+		widget.NewButton(T(ResetColumnWidthsButton), func() {
+			for _, key := range columnPreferences {
+				preferences.RemoveValue(key)
+			}
+			settingsDialog.Hide()
+			resetCallback()
+		}),
+		widget.NewButton(T(ResetWindowSizeButton), func() {
+			window.Resize(fyne.NewSize(DefaultWindowWidth, DefaultWindowHeight))
+		}),
+	)
+	settingsDialog = dialog.NewCustom(T(SettingsLabel), T(SettingsCloseButton), content, window)
+	return settingsDialog
+}
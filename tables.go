@@ -0,0 +1,590 @@
+package main
+
+import (
+	"cmp"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"image/color"
+	"jubilant-spork/iul"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	StatusColumnWidth          = 100
+	ProblemsColumnWidth        = 250
+	FilenameColumnWidth        = 300
+	ChecksumColumnWidth        = 200
+	SizeColumnWidth            = 150
+	CreatedColumnWidth         = 250
+	AuthorTableColumnWidth     = 400
+	ControlTableMinColumnWidth = 40
+	ControlTableMaxColumnWidth = 400
+	FileStatusKeyPrefix        = "file_status."
+)
+
+// fileRow is everything a row of the file table shows about a file.
+type fileRow struct {
+	File     iul.CheckedFile
+	Status   iul.FileStatus
+	Problems []iul.Problem
+}
+
+type fileColumn struct {
+	Header string
+	Width  float32
+	Value  func(row fileRow, profile iul.FormatProfile) string
+	// Compare orders the rows when the column is sorted; the values are
+	// compared as text when it is nil.
+	Compare func(a, b fileRow) int
+}
+
+func (c fileColumn) compare(a, b fileRow, profile iul.FormatProfile) int {
+	if c.Compare != nil {
+		return c.Compare(a, b)
+	}
+	return strings.Compare(strings.ToLower(c.Value(a, profile)), strings.ToLower(c.Value(b, profile)))
+}
+
+var fileColumns = []fileColumn{
+	{Header: "file_table.status", Width: StatusColumnWidth, Value: func(row fileRow, _ iul.FormatProfile) string {
+		if row.Status == iul.StatusUnchanged {
+			return ""
+		}
+		return T(FileStatusKeyPrefix + string(row.Status))
+	}},
+	{Header: "file_table.problems", Width: ProblemsColumnWidth, Value: func(row fileRow, _ iul.FormatProfile) string {
+		if len(row.Problems) == 0 {
+			return ""
+		}
+		return problemText(row.Problems[0])
+	}},
+	{Header: "file_table.name", Width: FilenameColumnWidth, Value: func(row fileRow, _ iul.FormatProfile) string {
+		return row.File.FileName
+	}},
+	{Header: "file_table.checksum", Width: ChecksumColumnWidth, Value: func(row fileRow, _ iul.FormatProfile) string {
+		return row.File.Checksum
+	}},
+	{Header: "file_table.size", Width: SizeColumnWidth, Value: func(row fileRow, profile iul.FormatProfile) string {
+		return profile.FormatSize(row.File.FileSize)
+	}, Compare: func(a, b fileRow) int {
+		return cmp.Compare(a.File.FileSize, b.File.FileSize)
+	}},
+	{Header: "file_table.created", Width: CreatedColumnWidth, Value: func(row fileRow, profile iul.FormatProfile) string {
+		return profile.FormatDate(row.File.CreatedAt)
+	}, Compare: func(a, b fileRow) int {
+		return a.File.CreatedAt.Compare(b.File.CreatedAt)
+	}},
+}
+
+type changeColumn struct {
+	Header string
+	Width  float32
+	Value  func(change iul.FileChange, profile iul.FormatProfile) string
+}
+
+var changeColumns = []changeColumn{
+	{Header: "file_table.status", Width: StatusColumnWidth, Value: func(change iul.FileChange, _ iul.FormatProfile) string {
+		return T(FileStatusKeyPrefix + string(change.Status))
+	}},
+	{Header: "file_table.name", Width: FilenameColumnWidth, Value: func(change iul.FileChange, _ iul.FormatProfile) string {
+		return change.File.FileName
+	}},
+	{Header: "changes_table.previous_checksum", Width: ChecksumColumnWidth, Value: func(change iul.FileChange, _ iul.FormatProfile) string {
+		return change.Previous.Checksum
+	}},
+	{Header: "file_table.checksum", Width: ChecksumColumnWidth, Value: func(change iul.FileChange, _ iul.FormatProfile) string {
+		if change.Status == iul.StatusRemoved {
+			return ""
+		}
+		return change.File.Checksum
+	}},
+	{Header: "file_table.size", Width: SizeColumnWidth, Value: func(change iul.FileChange, profile iul.FormatProfile) string {
+		return profile.FormatSize(change.File.FileSize)
+	}},
+}
+
+var authorTableHeaders = [3]string{"author_table.title", "author_table.name", "author_table.selection"}
+
+func updateRowHeader(id widget.TableCellID, template fyne.CanvasObject, header func(col int) string) {
+	label := template.(*widget.Label)
+	if id.Row < 0 {
+		label.SetText(header(id.Col))
+	} else if id.Col < 0 {
+		label.SetText(strconv.Itoa(id.Row + 1))
+	} else {
+		label.SetText("")
+	}
+}
+
+// headerLabel is a table header that reports taps.
+type headerLabel struct {
+	widget.Label
+	OnTapped func()
+}
+
+func newHeaderLabel() fyne.CanvasObject {
+	label := &headerLabel{}
+	label.TextStyle.Bold = true
+	label.Alignment = fyne.TextAlignCenter
+	label.ExtendBaseWidget(label)
+	return label
+}
+
+func (l *headerLabel) Tapped(*fyne.PointEvent) {
+	if l.OnTapped != nil {
+		l.OnTapped()
+	}
+}
+
+// FileTable shows the project files that match the quick filter. Tapping a
+// column header sorts the project files, and so the document items, by that
+// column; tapping it again reverses the order.
+type FileTable struct {
+	*widget.Table
+	// OnChanged is called after the shown files change.
+	OnChanged func()
+
+	bindings   *ProjectBinding
+	profile    *iul.FormatProfile
+	filter     string
+	rows       []int
+	sortColumn int
+	descending bool
+}
+
+func CreateFileDataTable(bindings *ProjectBinding, profile *iul.FormatProfile, preferences fyne.Preferences) *FileTable {
+	fileTable := &FileTable{bindings: bindings, profile: profile, sortColumn: -1}
+	table := &widget.Table{
+		Length: func() (rows int, cols int) {
+			if len(fileTable.rows) == 0 {
+				return 0, 0
+			}
+			return len(fileTable.rows), len(fileColumns)
+		},
+		CreateCell: func() fyne.CanvasObject {
+			label := widget.NewLabel(PlaceholderLabel)
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		CreateHeader: newHeaderLabel,
+		UpdateCell:   func(id widget.TableCellID, object fyne.CanvasObject) {},
+	}
+	table.ExtendBaseWidget(table)
+	fileTable.Table = table
+	table.UpdateCell = func(id widget.TableCellID, object fyne.CanvasObject) {
+		object.(*widget.Label).SetText(fileTable.CellText(id))
+	}
+	table.ShowHeaderRow = true
+	table.ShowHeaderColumn = true
+	table.UpdateHeader = func(id widget.TableCellID, template fyne.CanvasObject) {
+		header := template.(*headerLabel)
+		header.OnTapped = nil
+		updateRowHeader(id, &header.Label, func(col int) string {
+			header.OnTapped = func() {
+				fileTable.Sort(col)
+			}
+			text := T(fileColumns[col].Header)
+			if col == fileTable.sortColumn && fileTable.descending {
+				text += " ↓"
+			} else if col == fileTable.sortColumn {
+				text += " ↑"
+			}
+			return text
+		})
+	}
+	widths := make([]float32, len(fileColumns))
+	for i, column := range fileColumns {
+		widths[i] = column.Width
+	}
+	persistColumnWidths(table, preferences, FileColumnsPreference, widths)
+	onListChange(bindings.Files, fileTable.Update)
+	bindings.Problems.AddListener(binding.NewDataListener(fileTable.Update))
+	return fileTable
+}
+
+func (t *FileTable) row(index int) fileRow {
+	file := bindingValue[iul.CheckedFile](t.bindings.Files, index)
+	return fileRow{File: file, Status: t.bindings.Project.FileStatus(file), Problems: t.bindings.FileProblems(file)}
+}
+
+func (t *FileTable) matches(row fileRow) bool {
+	if t.filter == "" {
+		return true
+	}
+	for _, column := range fileColumns {
+		if strings.Contains(strings.ToLower(column.Value(row, *t.profile)), t.filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// Update lists the files that match the filter and refreshes the table.
+func (t *FileTable) Update() {
+	var rows []int
+	for i := range t.bindings.Files.Length() {
+		if t.matches(t.row(i)) {
+			rows = append(rows, i)
+		}
+	}
+	t.rows = rows
+	t.Refresh()
+	if t.OnChanged != nil {
+		t.OnChanged()
+	}
+}
+
+// SetFilter shows only the files with the text in any of the columns.
+func (t *FileTable) SetFilter(text string) {
+	t.filter = strings.ToLower(strings.TrimSpace(text))
+	t.UnselectAll()
+	t.Update()
+}
+
+// Filtered tells whether the filter hides some of the files.
+func (t *FileTable) Filtered() bool {
+	return t.filter != ""
+}
+
+// CellText returns the full text of a shown cell.
+func (t *FileTable) CellText(id widget.TableCellID) string {
+	if id.Row < 0 || id.Row >= len(t.rows) || id.Col < 0 || id.Col >= len(fileColumns) {
+		return ""
+	}
+	return fileColumns[id.Col].Value(t.row(t.rows[id.Row]), *t.profile)
+}
+
+// Summary returns the number of shown files and their total size. Archive
+// entries are counted but their size is part of the archive size.
+func (t *FileTable) Summary() (count int, size int64) {
+	for _, index := range t.rows {
+		file := bindingValue[iul.CheckedFile](t.bindings.Files, index)
+		if file.Archive == "" {
+			size += file.FileSize
+		}
+	}
+	return len(t.rows), size
+}
+
+// Sort orders the project files by the column.
+func (t *FileTable) Sort(col int) {
+	t.descending = col == t.sortColumn && !t.descending
+	t.sortColumn = col
+	project := t.bindings.Project
+	rows := make(map[string]fileRow, len(project.Files))
+	for i := range project.Files {
+		rows[project.Files[i].FileName] = t.row(i)
+	}
+	compare := fileColumns[col].compare
+	slices.SortStableFunc(project.Files, func(a, b iul.CheckedFile) int {
+		result := compare(rows[a.FileName], rows[b.FileName], *t.profile)
+		if t.descending {
+			return -result
+		}
+		return result
+	})
+	t.bindings.Sync()
+}
+
+// ResetSort drops the sort mark after the files are reordered by hand.
+func (t *FileTable) ResetSort() {
+	t.sortColumn = -1
+	t.descending = false
+}
+
+// CreateChangesTable lists the files compared with the previous issue.
+func CreateChangesTable(bindings *ProjectBinding, profile *iul.FormatProfile, preferences fyne.Preferences) *widget.Table {
+	changes := bindings.Changes
+	table := &widget.Table{
+		Length: func() (rows int, cols int) {
+			rowsCount := changes.Length()
+			if rowsCount == 0 {
+				return 0, 0
+			}
+			return rowsCount, len(changeColumns)
+		},
+		CreateCell: func() fyne.CanvasObject {
+			return widget.NewLabel(PlaceholderLabel)
+		},
+		UpdateCell: func(id widget.TableCellID, object fyne.CanvasObject) {
+			label := object.(*widget.Label)
+			change := bindingValue[iul.FileChange](changes, id.Row)
+			label.SetText(changeColumns[id.Col].Value(change, *profile))
+		},
+	}
+	table.ExtendBaseWidget(table)
+	table.ShowHeaderRow = true
+	table.ShowHeaderColumn = true
+	table.UpdateHeader = func(id widget.TableCellID, template fyne.CanvasObject) {
+		updateRowHeader(id, template, func(col int) string {
+			return T(changeColumns[col].Header)
+		})
+	}
+	widths := make([]float32, len(changeColumns))
+	for i, column := range changeColumns {
+		widths[i] = column.Width
+	}
+	persistColumnWidths(table, preferences, ChangeColumnsPreference, widths)
+	refreshOnChange(changes, table)
+	return table
+}
+
+func newMoveButtons(table *widget.Table, selectedCell *widget.TableCellID, length func() int, move func(src, dst int)) (*widget.Button, *widget.Button) {
+	upButton := widget.NewButtonWithIcon("", theme.MenuDropUpIcon(), func() {
+		// Move selected row up
+		if selectedCell.Row < length() && selectedCell.Row > 0 {
+			move(selectedCell.Row, selectedCell.Row-1)
+			table.Select(widget.TableCellID{Row: selectedCell.Row - 1, Col: selectedCell.Col})
+		} else {
+			move(selectedCell.Row, selectedCell.Row-1)
+			table.Select(widget.TableCellID{Row: length() - 1, Col: selectedCell.Col})
+		}
+	})
+	downButton := widget.NewButtonWithIcon("", theme.MenuDropDownIcon(), func() {
+		// Move selected row down
+		if selectedCell.Row < length()-1 && selectedCell.Row > -1 {
+			move(selectedCell.Row, selectedCell.Row+1)
+			table.Select(widget.TableCellID{Row: selectedCell.Row + 1, Col: selectedCell.Col})
+		} else {
+			move(selectedCell.Row, selectedCell.Row+1)
+			table.Select(widget.TableCellID{Row: 0, Col: selectedCell.Col})
+		}
+	})
+	return upButton, downButton
+}
+
+func CreateFileTableLayout(table *FileTable, bindings *ProjectBinding, profile *iul.FormatProfile) *fyne.Container {
+	var selectedCell widget.TableCellID
+	selectedText := widget.NewLabel("")
+	selectedText.Truncation = fyne.TextTruncateEllipsis
+	summary := widget.NewLabel("")
+	table.OnSelected = func(id widget.TableCellID) {
+		selectedCell = id
+		selectedText.SetText(table.CellText(id))
+	}
+	table.OnChanged = func() {
+		count, size := table.Summary()
+		summary.SetText(T(FileSummaryTemplate, count, bindings.Files.Length(), profile.FormatSize(size)))
+		selectedText.SetText(table.CellText(selectedCell))
+	}
+	table.OnChanged()
+	upButton, downButton := newMoveButtons(table.Table, &selectedCell, bindings.Files.Length, func(src, dst int) {
+		bindings.Project.MoveFile(src, dst)
+		table.ResetSort()
+		bindings.Sync()
+	})
+	filter := widget.NewEntry()
+	filter.SetPlaceHolder(T(FileFilterPlaceholder))
+	filter.OnChanged = func(text string) {
+		table.SetFilter(text)
+		// Rows are moved by their index in the whole list.
+		if table.Filtered() {
+			upButton.Disable()
+			downButton.Disable()
+		} else {
+			upButton.Enable()
+			downButton.Enable()
+		}
+	}
+	return container.NewBorder(
+		filter,
+		container.NewBorder(nil, nil, nil, summary, selectedText),
+		nil,
+		container.NewGridWithColumns(1, upButton, downButton),
+		table.Table,
+	)
+}
+
+// controlColumnWidths fits the columns to their longest value. Values of
+// cells merged across columns are left out.
+func controlColumnWidths(control iul.ControlSheet, profile *iul.FormatProfile) []float32 {
+	widths := make([]float32, control.ColumnCount())
+	for i := range widths {
+		widths[i] = ControlTableMinColumnWidth
+	}
+	padding := 2 * theme.InnerPadding()
+	for row := range control.Rows {
+		for col := range control.Rows[row] {
+			value := profile.FormatCell(control, row, col)
+			if merged, ok := control.MergedAt(row, col); value == "" || ok && merged.StartCol != merged.EndCol {
+				continue
+			}
+			width := fyne.MeasureText(value, theme.TextSize(), fyne.TextStyle{}).Width + padding
+			widths[col] = min(max(widths[col], width), ControlTableMaxColumnWidth)
+		}
+	}
+	return widths
+}
+
+func highlightColor() color.Color {
+	highlight := color.NRGBAModel.Convert(theme.PrimaryColor()).(color.NRGBA)
+	highlight.A = 0x60
+	return highlight
+}
+
+// CreateControlTable shows the control sheet with Excel column and row names.
+// Merged regions are shaded and the cells used by the template highlighted.
+// Dates are formatted with the profile.
+func CreateControlTable(bindings *ProjectBinding, profile *iul.FormatProfile, templateCells binding.StringList) *widget.Table {
+	var referenced map[string]bool
+	table := widget.NewTableWithHeaders(
+		func() (rows int, cols int) {
+			control := bindings.Project.Control
+			if control.RowCount() == 0 {
+				return 0, 0
+			}
+			return control.RowCount(), control.ColumnCount()
+		},
+		func() fyne.CanvasObject {
+			label := widget.NewLabel(PlaceholderLabel)
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewStack(canvas.NewRectangle(color.Transparent), label)
+		},
+		func(id widget.TableCellID, object fyne.CanvasObject) {
+			control := bindings.Project.Control
+			stack := object.(*fyne.Container)
+			background := stack.Objects[0].(*canvas.Rectangle)
+			stack.Objects[1].(*widget.Label).SetText(profile.FormatCell(control, id.Row, id.Col))
+			background.FillColor = color.Transparent
+			if _, ok := control.MergedAt(id.Row, id.Col); ok {
+				background.FillColor = theme.InputBackgroundColor()
+			}
+			if referenced[iul.CellName(id.Row, id.Col)] {
+				background.FillColor = highlightColor()
+			}
+			background.Refresh()
+		},
+	)
+	table.UpdateHeader = func(id widget.TableCellID, template fyne.CanvasObject) {
+		updateRowHeader(id, template, iul.ColumnName)
+	}
+	onListChange(bindings.Control, func() {
+		for i, width := range controlColumnWidths(bindings.Project.Control, profile) {
+			table.SetColumnWidth(i, width)
+		}
+		table.Refresh()
+	})
+	templateCells.AddListener(binding.NewDataListener(func() {
+		cells, _ := templateCells.Get()
+		referenced = make(map[string]bool, len(cells))
+		for _, cell := range cells {
+			referenced[cell] = true
+		}
+		table.Refresh()
+	}))
+	return table
+}
+
+// CreateControlTableLayout shows the full value of the selected cell, or of
+// the merged region it belongs to, above the table.
+func CreateControlTableLayout(table *widget.Table, bindings *ProjectBinding, profile *iul.FormatProfile) *fyne.Container {
+	cellText := widget.NewLabel("")
+	cellText.Wrapping = fyne.TextWrapWord
+	table.OnSelected = func(id widget.TableCellID) {
+		control := bindings.Project.Control
+		row, col := id.Row, id.Col
+		if merged, ok := control.MergedAt(row, col); ok {
+			row, col = merged.StartRow, merged.StartCol
+		}
+		cellText.SetText(iul.CellName(row, col) + ": " + profile.FormatCell(control, row, col))
+	}
+	return container.NewBorder(cellText, widget.NewLabel(T(TemplateCellsHint)), nil, nil, table)
+}
+
+func CreateAuthorTable(bindings *ProjectBinding, preferences fyne.Preferences) *widget.Table {
+	table := &widget.Table{
+		Length: func() (rows int, cols int) {
+			rowsCount := bindings.Authors.Length()
+			if rowsCount == 0 {
+				return 0, 0
+			}
+			return rowsCount, len(authorTableHeaders)
+		},
+		CreateCell: func() fyne.CanvasObject {
+			titleSelect := widget.NewSelect(nil, nil)
+			entry := widget.NewEntry()
+			return container.NewStack(titleSelect, entry, widget.NewLabel(""))
+		},
+		UpdateCell: func(id widget.TableCellID, object fyne.CanvasObject) {},
+	}
+	table.UpdateCell = func(id widget.TableCellID, object fyne.CanvasObject) {
+		author := bindingValue[iul.Author](bindings.Authors, id.Row)
+		stack := object.(*fyne.Container)
+		titleSelect := stack.Objects[0].(*widget.Select)
+		entry := stack.Objects[1].(*widget.Entry)
+		label := stack.Objects[2]
+		titleSelect.Hide()
+		entry.Hide()
+		label.Hide()
+		switch id.Col {
+		case 0:
+			titleSelect.OnChanged = nil
+			titleSelect.Options = bindings.Project.AuthorTitles
+			titleSelect.Selected = author.Title
+			titleSelect.OnChanged = func(s string) {
+				author := bindingValue[iul.Author](bindings.Authors, id.Row)
+				author.Title = s
+				bindings.SetAuthor(id.Row, author)
+			}
+			titleSelect.Show()
+			titleSelect.Refresh()
+		case 1:
+			entry.OnChanged = nil
+			if entry.Text != author.Name {
+				entry.SetText(author.Name)
+			}
+			entry.OnChanged = func(s string) {
+				author := bindingValue[iul.Author](bindings.Authors, id.Row)
+				author.Name = s
+				bindings.SetAuthor(id.Row, author)
+			}
+			entry.Show()
+		default:
+			label.Show()
+		}
+	}
+
+	table.UpdateHeader = func(id widget.TableCellID, template fyne.CanvasObject) {
+		updateRowHeader(id, template, func(col int) string {
+			return T(authorTableHeaders[col])
+		})
+	}
+	table.ExtendBaseWidget(table)
+	table.ShowHeaderRow = true
+	table.ShowHeaderColumn = true
+	widths := make([]float32, len(authorTableHeaders))
+	for i := range widths {
+		widths[i] = AuthorTableColumnWidth
+	}
+	persistColumnWidths(table, preferences, AuthorColumnsPreference, widths)
+	refreshOnChange(bindings.Authors, table)
+	return table
+}
+
+func CreateAuthorTableLayout(table *widget.Table, bindings *ProjectBinding) *fyne.Container {
+	var selectedCell = widget.TableCellID{Row: -1, Col: -1}
+	table.OnSelected = func(id widget.TableCellID) {
+		if id.Col != 0 {
+			table.Select(widget.TableCellID{Row: id.Row, Col: 2})
+		}
+		selectedCell = id
+	}
+	upButton, downButton := newMoveButtons(table, &selectedCell, bindings.Authors.Length, func(src, dst int) {
+		bindings.Project.MoveAuthor(src, dst)
+		bindings.Sync()
+	})
+	deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		if selectedCell.Row < 0 {
+			return
+		}
+		bindings.Project.RemoveAuthor(selectedCell.Row)
+		bindings.Sync()
+	})
+	return container.NewBorder(nil, nil, nil, container.NewGridWithColumns(1, upButton, downButton, deleteButton), table)
+}